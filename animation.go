@@ -0,0 +1,133 @@
+package gosom
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+)
+
+// An Animation records snapshots of the U-Matrix or selected component planes
+// of a SOM during training and encodes them as an animated GIF.
+type Animation struct {
+	SOM *SOM
+
+	// A snapshot is taken every Interval steps and after the last step.
+	Interval int
+
+	// The size of the individual nodes.
+	NodeWidth int
+
+	// The component planes that are drawn side by side. The U-Matrix is drawn
+	// if no planes are selected.
+	Planes []int
+
+	// The options used to draw the snapshots. The titles are used as names
+	// for the component planes.
+	Options DrawOptions
+
+	// The delay between the frames in 100ths of a second.
+	Delay int
+
+	snapshots []image.Image
+}
+
+// NewAnimation returns a new Animation that records the U-Matrix every
+// interval steps.
+func NewAnimation(som *SOM, interval, nodeWidth int) *Animation {
+	return &Animation{
+		SOM:       som,
+		Interval:  interval,
+		NodeWidth: nodeWidth,
+		Options: DrawOptions{
+			ColorMap: "gray",
+			Invert:   true,
+		},
+		Delay: 10,
+	}
+}
+
+// Snapshot draws the current state of the SOM as a new frame.
+func (a *Animation) Snapshot(step int) {
+	var images []image.Image
+
+	if len(a.Planes) == 0 {
+		options := a.Options
+		options.Titles = []string{fmt.Sprintf("step %d", step)}
+		images = append(images, drawValues(a.SOM, a.SOM.UMatrix(), a.NodeWidth, options, newRaster).(*raster).img)
+	} else {
+		options := a.Options
+		options.Titles = make([]string, a.SOM.Dimensions())
+		matrix := a.SOM.WeightMatrix()
+
+		// only the selected planes are drawn
+		for _, plane := range a.Planes {
+			if plane < 0 || plane >= a.SOM.Dimensions() {
+				continue
+			}
+
+			name := a.Options.title(plane)
+			if name == "" {
+				name = fmt.Sprintf("dimension %d", plane)
+			}
+
+			options.Titles[plane] = fmt.Sprintf("%s, step %d", name, step)
+			images = append(images, drawDimension(a.SOM, matrix, plane, a.NodeWidth, options, newRaster).(*raster).img)
+		}
+	}
+
+	// arrange images side by side
+	width, height := 0, 0
+	for _, img := range images {
+		width += img.Bounds().Dx()
+		height = max(height, img.Bounds().Dy())
+	}
+
+	snapshot := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(snapshot, snapshot.Bounds(), image.White, image.Point{}, draw.Src)
+
+	x := 0
+	for _, img := range images {
+		draw.Draw(snapshot, img.Bounds().Add(image.Pt(x, 0)), img, img.Bounds().Min, draw.Src)
+		x += img.Bounds().Dx()
+	}
+
+	a.snapshots = append(a.snapshots, snapshot)
+}
+
+// Frames returns the number of recorded frames.
+func (a *Animation) Frames() int {
+	return len(a.snapshots)
+}
+
+// Encode writes the recorded frames as an animated GIF to w.
+func (a *Animation) Encode(w io.Writer) error {
+	// frames may differ in size due to their titles
+	width, height := 0, 0
+	for _, snapshot := range a.snapshots {
+		width = max(width, snapshot.Bounds().Dx())
+		height = max(height, snapshot.Bounds().Dy())
+	}
+
+	animation := &gif.GIF{
+		Config: image.Config{
+			ColorModel: color.Palette(palette.Plan9),
+			Width:      width,
+			Height:     height,
+		},
+	}
+
+	for _, snapshot := range a.snapshots {
+		frame := image.NewPaletted(image.Rect(0, 0, width, height), palette.Plan9)
+		draw.Draw(frame, frame.Bounds(), image.White, image.Point{}, draw.Src)
+		draw.FloydSteinberg.Draw(frame, snapshot.Bounds(), snapshot, image.Point{})
+
+		animation.Image = append(animation.Image, frame)
+		animation.Delay = append(animation.Delay, a.Delay)
+	}
+
+	return gif.EncodeAll(w, animation)
+}
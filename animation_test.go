@@ -0,0 +1,47 @@
+package gosom
+
+import (
+	"bytes"
+	"image/gif"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnimation(t *testing.T) {
+	data := NewMatrix([][]float64{{0, 0}, {1, 1}, {0, 1}, {1, 0}})
+
+	som := NewSOM(3, 3)
+	som.InitializeWithRandomValues(data)
+
+	animation := NewAnimation(som, 4, 5)
+
+	training := NewTraining(som, 10, 0.5, 0.1, 2, 0.5)
+	training.Animation = animation
+	som.Train(data, training)
+
+	// steps 0, 4, 8 and 10
+	assert.Equal(t, 4, animation.Frames())
+
+	var buf bytes.Buffer
+	require.NoError(t, animation.Encode(&buf))
+
+	decoded, err := gif.DecodeAll(&buf)
+	require.NoError(t, err)
+	assert.Len(t, decoded.Image, 4)
+	assert.Equal(t, []int{10, 10, 10, 10}, decoded.Delay)
+}
+
+func TestAnimationPlanes(t *testing.T) {
+	som := NewSOM(3, 3)
+	som.InitializeWithZeroes(3)
+
+	animation := NewAnimation(som, 1, 5)
+	animation.Planes = []int{0, 2}
+	animation.Snapshot(0)
+
+	assert.Equal(t, 1, animation.Frames())
+	assert.Equal(t, 2*(len("dimension 0, step 0")*7+8), animation.snapshots[0].Bounds().Dx())
+	assert.Equal(t, 15+titleHeight, animation.snapshots[0].Bounds().Dy())
+}
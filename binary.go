@@ -0,0 +1,264 @@
+package gosom
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// The magic bytes and version that start a binary SOM.
+const (
+	binaryMagic   = "GSOM"
+	binaryVersion = 1
+)
+
+// maxBinaryValues is the largest number of weights that is accepted from a
+// binary header.
+const maxBinaryValues = 1 << 31
+
+// binaryHeader is the fixed size part of the binary format.
+type binaryHeader struct {
+	Magic     [4]byte
+	Version   uint16
+	Precision uint16
+	Width     uint32
+	Height    uint32
+	Dims      uint32
+}
+
+// IsBinary reports whether the header starts with the magic bytes of the
+// binary format.
+func IsBinary(header []byte) bool {
+	return len(header) >= len(binaryMagic) && string(header[:len(binaryMagic)]) == binaryMagic
+}
+
+// SaveBinary writes the SOM in the compact binary format to destination. The
+// weights are stored with the specified precision, which can be 64 or 32
+// bits.
+//
+// The format starts with a header (magic, version, precision, width, height,
+// dimensions), followed by the function names and topology as length prefixed
+// strings, the optional detector and the weights of all nodes in row-major
+// order. All numbers are little-endian.
+func (som *SOM) SaveBinary(destination io.Writer, precision int) error {
+	if precision != 64 && precision != 32 {
+		return fmt.Errorf("gosom: unsupported precision %d", precision)
+	}
+
+	if len(som.Nodes) == 0 {
+		return errors.New("gosom: no nodes")
+	}
+
+	w := bufio.NewWriter(destination)
+
+	header := binaryHeader{
+		Version:   binaryVersion,
+		Precision: uint16(precision),
+		Width:     uint32(som.Width),
+		Height:    uint32(som.Height),
+		Dims:      uint32(som.Dimensions()),
+	}
+	copy(header.Magic[:], binaryMagic)
+
+	err := binary.Write(w, binary.LittleEndian, header)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range []string{som.CoolingFunction, som.DistanceFunction, som.NeighborhoodFunction, som.Topology} {
+		err = writeBinaryString(w, s)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = writeBinaryDetector(w, som.Detector)
+	if err != nil {
+		return err
+	}
+
+	size := precision / 8
+	buf := make([]byte, som.Dimensions()*size)
+
+	for _, node := range som.Nodes {
+		for i, weight := range node.Weights {
+			if precision == 64 {
+				binary.LittleEndian.PutUint64(buf[i*size:], math.Float64bits(weight))
+			} else {
+				binary.LittleEndian.PutUint32(buf[i*size:], math.Float32bits(float32(weight)))
+			}
+		}
+
+		_, err = w.Write(buf)
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+// LoadBinary reads a SOM in the binary format written by SaveBinary from
+// source. SOMs stored with 32 bits use single precision.
+func LoadBinary(source io.Reader) (*SOM, error) {
+	r := bufio.NewReader(source)
+
+	var header binaryHeader
+	err := binary.Read(r, binary.LittleEndian, &header)
+	if err != nil {
+		return nil, err
+	}
+
+	if !IsBinary(header.Magic[:]) {
+		return nil, errors.New("gosom: invalid magic")
+	}
+
+	if header.Version != binaryVersion {
+		return nil, fmt.Errorf("gosom: unsupported version %d", header.Version)
+	}
+
+	if header.Precision != 64 && header.Precision != 32 {
+		return nil, fmt.Errorf("gosom: unsupported precision %d", header.Precision)
+	}
+
+	if header.Width == 0 || header.Height == 0 || header.Dims == 0 {
+		return nil, errors.New("gosom: invalid size")
+	}
+
+	// the factors are below 2^32 so that the checks cannot overflow
+	nodes := uint64(header.Width) * uint64(header.Height)
+	if nodes > maxBinaryValues || nodes*uint64(header.Dims) > maxBinaryValues {
+		return nil, fmt.Errorf("gosom: size %dx%dx%d exceeds limit", header.Width, header.Height, header.Dims)
+	}
+
+	som := NewSOM(int(header.Width), int(header.Height))
+
+	if header.Precision == 32 {
+		som.Precision = 32
+	}
+
+	for _, s := range []*string{&som.CoolingFunction, &som.DistanceFunction, &som.NeighborhoodFunction, &som.Topology} {
+		*s, err = readBinaryString(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	som.Detector, err = readBinaryDetector(r, int(nodes))
+	if err != nil {
+		return nil, err
+	}
+
+	if som.Detector != nil {
+		som.Detector.SOM = som
+	}
+
+	som.Nodes = NewLattice(som.Width, som.Height, int(header.Dims))
+
+	// decode the weights node by node into the lattice buffer
+	size := int(header.Precision) / 8
+	buf := make([]byte, int(header.Dims)*size)
+
+	for _, node := range som.Nodes {
+		_, err = io.ReadFull(r, buf)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range node.Weights {
+			if size == 8 {
+				node.Weights[i] = math.Float64frombits(binary.LittleEndian.Uint64(buf[i*size:]))
+			} else {
+				node.Weights[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(buf[i*size:])))
+			}
+		}
+	}
+
+	return som, nil
+}
+
+func writeBinaryString(w io.Writer, s string) error {
+	err := binary.Write(w, binary.LittleEndian, uint16(len(s)))
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, s)
+	return err
+}
+
+func readBinaryString(r io.Reader) (string, error) {
+	var length uint16
+	err := binary.Read(r, binary.LittleEndian, &length)
+	if err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, length)
+	_, err = io.ReadFull(r, buf)
+	if err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
+
+func writeBinaryDetector(w io.Writer, d *Detector) error {
+	if d == nil {
+		return binary.Write(w, binary.LittleEndian, uint8(0))
+	}
+
+	normalized := uint8(0)
+	if d.Normalized {
+		normalized = 1
+	}
+
+	for _, v := range []interface{}{uint8(1), normalized, d.Threshold, uint32(len(d.Errors)), d.Errors} {
+		err := binary.Write(w, binary.LittleEndian, v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readBinaryDetector(r io.Reader, nodes int) (*Detector, error) {
+	var present uint8
+	err := binary.Read(r, binary.LittleEndian, &present)
+	if err != nil || present == 0 {
+		return nil, err
+	}
+
+	d := &Detector{}
+
+	var normalized uint8
+	var count uint32
+	for _, v := range []interface{}{&normalized, &d.Threshold, &count} {
+		err = binary.Read(r, binary.LittleEndian, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if count != 0 && int(count) != nodes {
+		return nil, fmt.Errorf("gosom: expected %d detector errors but got %d", nodes, count)
+	}
+
+	d.Normalized = normalized == 1
+	d.Errors = make([]float64, count)
+
+	err = binary.Read(r, binary.LittleEndian, d.Errors)
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
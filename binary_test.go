@@ -0,0 +1,106 @@
+package gosom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinary(t *testing.T) {
+	som := NewSOM(3, 2)
+	som.Topology = "hexagonal"
+	som.DistanceFunction = "manhattan"
+	som.InitializeWithRandomValues(NewMatrix([][]float64{{0, 0, 0}, {1, 2, 3}}))
+
+	var buf bytes.Buffer
+	require.NoError(t, som.SaveBinary(&buf, 64))
+	assert.True(t, IsBinary(buf.Bytes()))
+	assert.Equal(t, 20+2*4+len("linearmanhattanconehexagonal")+1+6*3*8, buf.Len())
+
+	loaded, err := LoadBinary(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, som, loaded)
+}
+
+func TestBinaryFloat32(t *testing.T) {
+	som := NewSOM(2, 2)
+	som.InitializeWithRandomValues(NewMatrix([][]float64{{0, 0}, {1, 1}}))
+
+	var buf bytes.Buffer
+	require.NoError(t, som.SaveBinary(&buf, 32))
+
+	loaded, err := LoadBinary(&buf)
+	require.NoError(t, err)
+
+	assert.Equal(t, 32, loaded.Precision)
+
+	for i, node := range som.Nodes {
+		assert.InDeltaSlice(t, node.Weights, loaded.Nodes[i].Weights, 1e-6)
+		assert.Equal(t, node.Position, loaded.Nodes[i].Position)
+	}
+
+	assert.Error(t, som.SaveBinary(&buf, 16))
+}
+
+func TestBinaryDetector(t *testing.T) {
+	som := NewSOM(2, 1)
+	som.InitializeWithZeroes(2)
+
+	detector := NewDetector(som, true)
+	detector.Errors = []float64{1, 2}
+	detector.Threshold = 3
+
+	var buf bytes.Buffer
+	require.NoError(t, som.SaveBinary(&buf, 64))
+
+	loaded, err := LoadBinary(&buf)
+	require.NoError(t, err)
+	require.NotNil(t, loaded.Detector)
+	assert.Equal(t, loaded, loaded.Detector.SOM)
+	assert.True(t, loaded.Detector.Normalized)
+	assert.Equal(t, []float64{1, 2}, loaded.Detector.Errors)
+	assert.Equal(t, 3.0, loaded.Detector.Threshold)
+}
+
+func TestLoadBinaryInvalid(t *testing.T) {
+	_, err := LoadBinary(bytes.NewReader([]byte(`{"Width":1,"Height":1,"Nodes":[]}`)))
+	assert.Error(t, err)
+
+	assert.False(t, IsBinary([]byte("{}")))
+
+	header := func(width, height, dims uint32) []byte {
+		var buf bytes.Buffer
+		require.NoError(t, binary.Write(&buf, binary.LittleEndian, binaryHeader{
+			Magic:     [4]byte{'G', 'S', 'O', 'M'},
+			Version:   binaryVersion,
+			Precision: 64,
+			Width:     width,
+			Height:    height,
+			Dims:      dims,
+		}))
+
+		// empty strings and no detector
+		buf.Write(make([]byte, 9))
+
+		return buf.Bytes()
+	}
+
+	_, err = LoadBinary(bytes.NewReader(header(0, 1, 1)))
+	assert.Error(t, err)
+
+	_, err = LoadBinary(bytes.NewReader(header(1<<20, 1<<20, 1)))
+	assert.EqualError(t, err, "gosom: size 1048576x1048576x1 exceeds limit")
+
+	_, err = LoadBinary(bytes.NewReader(header(1<<16, 1<<15, 1<<16)))
+	assert.Error(t, err)
+
+	_, err = LoadBinary(bytes.NewReader(header(4, 4, 2)))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	err = NewSOM(1, 1).SaveBinary(io.Discard, 64)
+	assert.EqualError(t, err, "gosom: no nodes")
+}
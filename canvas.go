@@ -0,0 +1,113 @@
+package gosom
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/llgcode/draw2d/draw2dimg"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// A canvas is a drawing surface that is implemented by raster images and
+// SVGs. Polygon titles are only rendered if tooltips are supported.
+type canvas interface {
+	polygon(points []float64, c color.Color, title string)
+	line(x1, y1, x2, y2 float64, c color.Color)
+	text(x, y float64, text string, c color.Color)
+	gradient(x1, y1, x2, y2 float64, stops []color.Color)
+	tooltips() bool
+}
+
+// newCanvasFunc creates a canvas of the specified size.
+type newCanvasFunc func(width, height int) canvas
+
+// raster implements the canvas using a raster image.
+type raster struct {
+	img *image.RGBA
+	gc  *draw2dimg.GraphicContext
+}
+
+func newRaster(width, height int) canvas {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	return &raster{
+		img: img,
+		gc:  draw2dimg.NewGraphicContext(img),
+	}
+}
+
+func (r *raster) polygon(points []float64, c color.Color, title string) {
+	r.gc.SetFillColor(c)
+	r.gc.MoveTo(points[0], points[1])
+
+	for i := 2; i < len(points); i += 2 {
+		r.gc.LineTo(points[i], points[i+1])
+	}
+
+	r.gc.Close()
+	r.gc.Fill()
+}
+
+func (r *raster) line(x1, y1, x2, y2 float64, c color.Color) {
+	r.gc.SetStrokeColor(c)
+	r.gc.SetLineWidth(1)
+	r.gc.MoveTo(x1, y1)
+	r.gc.LineTo(x2, y2)
+	r.gc.Stroke()
+}
+
+func (r *raster) text(x, y float64, text string, c color.Color) {
+	drawText(r.img, int(x), int(y), text, c)
+}
+
+// gradient fills the pixels of the rectangle in one pass with colors that are
+// interpolated between the evenly spaced stops from top to bottom.
+func (r *raster) gradient(x1, y1, x2, y2 float64, stops []color.Color) {
+	rgba := make([]color.RGBA, len(stops))
+	for i, c := range stops {
+		rgba[i] = color.RGBAModel.Convert(c).(color.RGBA)
+	}
+
+	rect := image.Rect(int(x1), int(y1), int(math.Ceil(x2)), int(math.Ceil(y2)))
+	height := rect.Dy()
+	rect = rect.Intersect(r.img.Bounds())
+
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		c := color.RGBAModel.Convert(interpolateColor(rgba, float64(y-int(y1))/float64(max(height-1, 1)))).(color.RGBA)
+
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			r.img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+func (r *raster) tooltips() bool {
+	return false
+}
+
+// rasterImages returns the images of raster canvases.
+func rasterImages(canvases []canvas) []image.Image {
+	images := make([]image.Image, len(canvases))
+
+	for i, c := range canvases {
+		images[i] = c.(*raster).img
+	}
+
+	return images
+}
+
+// drawText draws text with the baseline starting at x and y.
+func drawText(img draw.Image, x, y int, text string, c color.Color) {
+	drawer := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+
+	drawer.DrawString(text)
+}
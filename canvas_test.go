@@ -0,0 +1,17 @@
+package gosom
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRasterGradient(t *testing.T) {
+	r := newRaster(4, 4).(*raster)
+	r.gradient(0, 0, 2, 4, []color.Color{color.White, color.Black})
+
+	assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, r.img.RGBAAt(0, 0))
+	assert.Equal(t, color.RGBA{R: 0, G: 0, B: 0, A: 255}, r.img.RGBAAt(1, 3))
+	assert.Equal(t, color.RGBA{}, r.img.RGBAAt(2, 0))
+}
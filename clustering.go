@@ -0,0 +1,307 @@
+package gosom
+
+import (
+	"math"
+	"math/rand"
+)
+
+// A Clustering groups the nodes of a SOM into clusters.
+type Clustering struct {
+	SOM      *SOM
+	Clusters []int
+}
+
+func newClustering(som *SOM, clusters []int) *Clustering {
+	// renumber clusters in order of appearance
+	ids := make(map[int]int)
+
+	for i, c := range clusters {
+		id, ok := ids[c]
+		if !ok {
+			id = len(ids)
+			ids[c] = id
+		}
+
+		clusters[i] = id
+	}
+
+	return &Clustering{
+		SOM:      som,
+		Clusters: clusters,
+	}
+}
+
+// KMeansClustering groups the nodes into k clusters using k-means on the
+// node weights.
+func KMeansClustering(som *SOM, k int) *Clustering {
+	weights := som.WeightMatrix()
+	k = max(1, min(k, weights.Rows))
+
+	// select initial centroids using k-means++
+	centroids := make([][]float64, 0, k)
+	centroids = append(centroids, weights.RandomRow())
+
+	distances := make([]float64, weights.Rows)
+
+	for len(centroids) < k {
+		total := 0.0
+
+		for i, row := range weights.Data {
+			distances[i] = math.Inf(1)
+
+			for _, centroid := range centroids {
+				distances[i] = math.Min(distances[i], som.D(row, centroid))
+			}
+
+			distances[i] *= distances[i]
+			total += distances[i]
+		}
+
+		r := rand.Float64() * total
+		next := weights.Rows - 1
+
+		for i, d := range distances {
+			r -= d
+			if r < 0 {
+				next = i
+				break
+			}
+		}
+
+		centroids = append(centroids, weights.Data[next])
+	}
+
+	clusters := make([]int, weights.Rows)
+
+	for iteration := 0; iteration < 100; iteration++ {
+		changed := false
+
+		// assign nodes to closest centroids
+		for i, row := range weights.Data {
+			best := 0
+			t := math.Inf(1)
+
+			for j, centroid := range centroids {
+				d := som.D(row, centroid)
+				if d < t {
+					t = d
+					best = j
+				}
+			}
+
+			if clusters[i] != best || iteration == 0 {
+				clusters[i] = best
+				changed = true
+			}
+		}
+
+		if !changed {
+			break
+		}
+
+		// recalculate centroids
+		counts := make([]int, k)
+		sums := make([][]float64, k)
+
+		for j := range sums {
+			sums[j] = make([]float64, weights.Columns)
+		}
+
+		for i, row := range weights.Data {
+			counts[clusters[i]]++
+
+			for d, value := range row {
+				sums[clusters[i]][d] += value
+			}
+		}
+
+		for j := range centroids {
+			if counts[j] == 0 {
+				continue
+			}
+
+			for d := range sums[j] {
+				sums[j][d] /= float64(counts[j])
+			}
+
+			centroids[j] = sums[j]
+		}
+	}
+
+	return newClustering(som, clusters)
+}
+
+// AgglomerativeClustering groups the nodes into k clusters by repeatedly
+// merging the two closest clusters that are adjacent on the lattice. The
+// linkage can be "ward" or "average". It returns nil if the linkage is
+// unknown.
+func AgglomerativeClustering(som *SOM, k int, linkage string) *Clustering {
+	if linkage != "ward" && linkage != "average" {
+		return nil
+	}
+
+	weights := som.WeightMatrix()
+	groups := make([]*cluster, weights.Rows)
+
+	for i, row := range weights.Data {
+		centroid := make([]float64, len(row))
+		copy(centroid, row)
+
+		groups[i] = &cluster{
+			members:   []int{i},
+			centroid:  centroid,
+			neighbors: make(map[int]float64),
+		}
+	}
+
+	link := func(a, b *cluster) float64 {
+		if linkage == "ward" {
+			d := som.D(a.centroid, b.centroid)
+			n := float64(len(a.members) * len(b.members))
+			return n / float64(len(a.members)+len(b.members)) * d * d
+		}
+
+		sum := 0.0
+		for _, i := range a.members {
+			for _, j := range b.members {
+				sum += som.D(weights.Data[i], weights.Data[j])
+			}
+		}
+
+		return sum / float64(len(a.members)*len(b.members))
+	}
+
+	for i := range groups {
+		for _, j := range som.adjacent(i) {
+			groups[i].neighbors[j] = link(groups[i], groups[j])
+		}
+	}
+
+	for remaining := len(groups); remaining > max(1, k); remaining-- {
+		// find closest adjacent clusters
+		a, b := -1, -1
+		t := math.Inf(1)
+
+		for i, group := range groups {
+			if group == nil {
+				continue
+			}
+
+			for j, d := range group.neighbors {
+				if d < t || (d == t && (i < a || (i == a && j < b))) {
+					t = d
+					a, b = i, j
+				}
+			}
+		}
+
+		if a < 0 {
+			break
+		}
+
+		// merge b into a
+		ga, gb := groups[a], groups[b]
+		na := float64(len(ga.members))
+		nb := float64(len(gb.members))
+
+		for d := range ga.centroid {
+			ga.centroid[d] = (ga.centroid[d]*na + gb.centroid[d]*nb) / (na + nb)
+		}
+
+		ga.members = append(ga.members, gb.members...)
+		groups[b] = nil
+
+		for j := range gb.neighbors {
+			if j != a {
+				ga.neighbors[j] = 0
+			}
+
+			delete(groups[j].neighbors, b)
+		}
+
+		delete(ga.neighbors, b)
+
+		for j := range ga.neighbors {
+			d := link(ga, groups[j])
+			ga.neighbors[j] = d
+			groups[j].neighbors[a] = d
+		}
+	}
+
+	clusters := make([]int, weights.Rows)
+
+	for i, group := range groups {
+		if group == nil {
+			continue
+		}
+
+		for _, member := range group.members {
+			clusters[member] = i
+		}
+	}
+
+	return newClustering(som, clusters)
+}
+
+// WatershedClustering segments the nodes by flooding the U-Matrix. Every
+// node descends to its lowest adjacent node until a local minimum is reached.
+// All nodes that reach the same minimum form a cluster.
+func WatershedClustering(som *SOM) *Clustering {
+	heights := som.UMatrix()
+
+	// determine steepest descent for every node
+	next := make([]int, len(som.Nodes))
+
+	for i := range som.Nodes {
+		next[i] = i
+
+		for _, j := range som.adjacent(i) {
+			h := heights[next[i]]
+
+			// plateaus are resolved towards the lower index to prevent cycles
+			if heights[j] < h || (heights[j] == h && j < next[i]) {
+				next[i] = j
+			}
+		}
+	}
+
+	clusters := make([]int, len(som.Nodes))
+
+	for i := range som.Nodes {
+		j := i
+		for next[j] != j {
+			j = next[j]
+		}
+
+		clusters[i] = j
+	}
+
+	return newClustering(som, clusters)
+}
+
+// Count returns the number of clusters.
+func (c *Clustering) Count() int {
+	count := 0
+
+	for _, cluster := range c.Clusters {
+		count = max(count, cluster+1)
+	}
+
+	return count
+}
+
+// Node returns the cluster of the node.
+func (c *Clustering) Node(node *Node) int {
+	return c.Clusters[node.Y()*c.SOM.Width+node.X()]
+}
+
+// Cluster returns the cluster of the closest node to the input.
+func (c *Clustering) Cluster(input []float64) int {
+	return c.Node(c.SOM.Closest(input))
+}
+
+type cluster struct {
+	members   []int
+	centroid  []float64
+	neighbors map[int]float64
+}
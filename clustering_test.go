@@ -0,0 +1,66 @@
+package gosom
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func clusteringSOM() *SOM {
+	som := NewSOM(4, 2)
+	som.Nodes = NewLattice(4, 2, 1)
+
+	for i, v := range []float64{0.0, 0.1, 0.9, 1.0, 0.1, 0.2, 0.8, 0.9} {
+		som.Nodes[i].Weights[0] = v
+	}
+
+	return som
+}
+
+func TestKMeansClustering(t *testing.T) {
+	c := KMeansClustering(clusteringSOM(), 2)
+
+	assert.Equal(t, 2, c.Count())
+	assert.Equal(t, c.Clusters[0], c.Clusters[1])
+	assert.Equal(t, c.Clusters[0], c.Clusters[5])
+	assert.Equal(t, c.Clusters[2], c.Clusters[3])
+	assert.Equal(t, c.Clusters[2], c.Clusters[6])
+	assert.NotEqual(t, c.Clusters[0], c.Clusters[2])
+}
+
+func TestAgglomerativeClustering(t *testing.T) {
+	for _, linkage := range []string{"ward", "average"} {
+		c := AgglomerativeClustering(clusteringSOM(), 2, linkage)
+
+		assert.Equal(t, []int{0, 0, 1, 1, 0, 0, 1, 1}, c.Clusters, linkage)
+	}
+
+	assert.Nil(t, AgglomerativeClustering(clusteringSOM(), 2, "foo"))
+}
+
+func TestAgglomerativeClusteringAdjacency(t *testing.T) {
+	som := NewSOM(3, 1)
+	som.Nodes = NewLattice(3, 1, 1)
+	som.Nodes[0].Weights[0] = 0.0
+	som.Nodes[1].Weights[0] = 1.0
+	som.Nodes[2].Weights[0] = 0.0
+
+	// the outer nodes are identical but not adjacent
+	c := AgglomerativeClustering(som, 2, "average")
+	assert.Equal(t, 2, c.Count())
+	assert.NotEqual(t, c.Clusters[0], c.Clusters[2])
+}
+
+func TestWatershedClustering(t *testing.T) {
+	c := WatershedClustering(clusteringSOM())
+
+	assert.Equal(t, []int{0, 0, 1, 1, 0, 0, 1, 1}, c.Clusters)
+}
+
+func TestClusteringCluster(t *testing.T) {
+	c := WatershedClustering(clusteringSOM())
+
+	assert.Equal(t, 0, c.Cluster([]float64{0.05}))
+	assert.Equal(t, 1, c.Cluster([]float64{0.95}))
+	assert.Equal(t, 1, c.Node(c.SOM.N(3, 1)))
+}
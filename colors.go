@@ -0,0 +1,141 @@
+package gosom
+
+import (
+	"image/color"
+	"math"
+)
+
+var viridis = []color.RGBA{
+	{0x44, 0x01, 0x54, 0xff},
+	{0x47, 0x2d, 0x7b, 0xff},
+	{0x3b, 0x52, 0x8b, 0xff},
+	{0x2c, 0x72, 0x8e, 0xff},
+	{0x21, 0x91, 0x8c, 0xff},
+	{0x28, 0xae, 0x80, 0xff},
+	{0x5e, 0xc9, 0x62, 0xff},
+	{0xad, 0xdc, 0x30, 0xff},
+	{0xfd, 0xe7, 0x25, 0xff},
+}
+
+var magma = []color.RGBA{
+	{0x00, 0x00, 0x04, 0xff},
+	{0x1c, 0x10, 0x44, 0xff},
+	{0x4f, 0x12, 0x7b, 0xff},
+	{0x81, 0x25, 0x81, 0xff},
+	{0xb5, 0x36, 0x7a, 0xff},
+	{0xe5, 0x50, 0x64, 0xff},
+	{0xfb, 0x87, 0x61, 0xff},
+	{0xfe, 0xc2, 0x87, 0xff},
+	{0xfc, 0xfd, 0xbf, 0xff},
+}
+
+var blueRed = []color.RGBA{
+	{0x21, 0x66, 0xac, 0xff},
+	{0x43, 0x93, 0xc3, 0xff},
+	{0x92, 0xc5, 0xde, 0xff},
+	{0xd1, 0xe5, 0xf0, 0xff},
+	{0xf7, 0xf7, 0xf7, 0xff},
+	{0xfd, 0xdb, 0xc7, 0xff},
+	{0xf4, 0xa5, 0x82, 0xff},
+	{0xd6, 0x60, 0x4d, 0xff},
+	{0xb2, 0x18, 0x2b, 0xff},
+}
+
+// GrayColor returns the gray color for the value [0..1].
+func GrayColor(value float64) color.Color {
+	return color.Gray{Y: uint8(clamp(value) * 255)}
+}
+
+// ViridisColor returns the color of the perceptual viridis color map for the
+// value [0..1].
+func ViridisColor(value float64) color.Color {
+	return interpolateColor(viridis, value)
+}
+
+// MagmaColor returns the color of the perceptual magma color map for the
+// value [0..1].
+func MagmaColor(value float64) color.Color {
+	return interpolateColor(magma, value)
+}
+
+// BlueRedColor returns the color of the diverging blue-red color map for the
+// value [0..1].
+func BlueRedColor(value float64) color.Color {
+	return interpolateColor(blueRed, value)
+}
+
+// Color returns the color of the value [0..1] based on the selected colorMap.
+func Color(colorMap string, value float64) color.Color {
+	switch colorMap {
+	case "gray":
+		return GrayColor(value)
+	case "viridis":
+		return ViridisColor(value)
+	case "magma":
+		return MagmaColor(value)
+	case "bluered":
+		return BlueRedColor(value)
+	}
+
+	return color.Black
+}
+
+func interpolateColor(stops []color.RGBA, value float64) color.Color {
+	p := clamp(value) * float64(len(stops)-1)
+	i := int(p)
+
+	if i >= len(stops)-1 {
+		return stops[len(stops)-1]
+	}
+
+	f := p - float64(i)
+	c := func(a, b uint8) uint8 {
+		return uint8(math.Round(float64(a) + (float64(b)-float64(a))*f))
+	}
+
+	return color.RGBA{
+		R: c(stops[i].R, stops[i+1].R),
+		G: c(stops[i].G, stops[i+1].G),
+		B: c(stops[i].B, stops[i+1].B),
+		A: 255,
+	}
+}
+
+func clamp(value float64) float64 {
+	if math.IsNaN(value) {
+		return 0
+	}
+
+	return math.Max(0, math.Min(1, value))
+}
+
+// clusterColor returns a distinct color for the cluster by rotating the hue
+// using the golden angle.
+func clusterColor(cluster int) color.Color {
+	h := math.Mod(float64(cluster)*137.508, 360) / 60
+	x := 1 - math.Abs(math.Mod(h, 2)-1)
+
+	var r, g, b float64
+
+	switch int(h) {
+	case 0:
+		r, g, b = 1, x, 0
+	case 1:
+		r, g, b = x, 1, 0
+	case 2:
+		r, g, b = 0, 1, x
+	case 3:
+		r, g, b = 0, x, 1
+	case 4:
+		r, g, b = x, 0, 1
+	default:
+		r, g, b = 1, 0, x
+	}
+
+	// use a saturation and value of 0.7 and 0.9
+	c := func(v float64) uint8 {
+		return uint8((0.9 - 0.9*0.7*(1-v)) * 255)
+	}
+
+	return color.RGBA{R: c(r), G: c(g), B: c(b), A: 255}
+}
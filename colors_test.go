@@ -0,0 +1,32 @@
+package gosom
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrayColor(t *testing.T) {
+	assert.Equal(t, color.Gray{Y: 0}, GrayColor(0))
+	assert.Equal(t, color.Gray{Y: 255}, GrayColor(1))
+	assert.Equal(t, color.Gray{Y: 0}, GrayColor(math.NaN()))
+}
+
+func TestColor(t *testing.T) {
+	assert.Equal(t, viridis[0], Color("viridis", 0))
+	assert.Equal(t, viridis[8], Color("viridis", 1))
+	assert.Equal(t, magma[4], Color("magma", 0.5))
+	assert.Equal(t, blueRed[8], Color("bluered", 2))
+	assert.Equal(t, color.Black, Color("foo", 0.5))
+}
+
+func TestInterpolateColor(t *testing.T) {
+	stops := []color.RGBA{{0, 0, 0, 255}, {100, 200, 50, 255}}
+	assert.Equal(t, color.RGBA{50, 100, 25, 255}, interpolateColor(stops, 0.5))
+}
+
+func TestClusterColor(t *testing.T) {
+	assert.NotEqual(t, clusterColor(0), clusterColor(1))
+}
@@ -0,0 +1,286 @@
+package gosom
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// CSVOptions configures the reading of CSV data.
+type CSVOptions struct {
+	// Delimiter separates the fields. Defaults to a comma.
+	Delimiter rune
+
+	// Comment starts lines that are ignored if set.
+	Comment rune
+
+	// Header treats the first record as the column names.
+	Header bool
+
+	// Missing lists the tokens that are read as NaNs, e.g. "NA", "NULL" or an
+	// empty string.
+	Missing []string
+
+	// Strict returns an error for values that are neither numbers nor missing
+	// tokens. Otherwise they are read as NaNs.
+	Strict bool
+
+	// Columns selects the columns by their index in the specified order. All
+	// columns are read if no columns are selected.
+	Columns []int
+
+	// ColumnNames selects the columns by their name in the header in the
+	// specified order. It requires Header to be set.
+	ColumnNames []string
+}
+
+// A CSVError reports an invalid record or value. Rows are the line numbers
+// of the source and columns are counted from one.
+type CSVError struct {
+	Row    int
+	Column int
+	Value  string
+	Err    error
+}
+
+// Error implements the error interface.
+func (e *CSVError) Error() string {
+	if e.Column == 0 {
+		return fmt.Sprintf("gosom: row %d: %s", e.Row, e.Err)
+	}
+
+	return fmt.Sprintf("gosom: row %d, column %d: %s %q", e.Row, e.Column, e.Err, e.Value)
+}
+
+// Unwrap returns the underlying error.
+func (e *CSVError) Unwrap() error {
+	return e.Err
+}
+
+// ErrInvalidValue is reported for values that cannot be parsed in strict mode.
+var ErrInvalidValue = errors.New("invalid value")
+
+// A CSVReader reads rows of CSV data one by one.
+type CSVReader struct {
+	options CSVOptions
+	reader  *csv.Reader
+	missing map[string]bool
+	columns []int
+	header  []string
+	names   []string
+	record  []string
+	fields  int
+}
+
+// NewCSVReader returns a new CSVReader that reads from source. The header is
+// read immediately if enabled.
+func NewCSVReader(source io.Reader, options CSVOptions) (*CSVReader, error) {
+	reader := csv.NewReader(source)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	if options.Delimiter != 0 {
+		reader.Comma = options.Delimiter
+	}
+
+	if options.Comment != 0 {
+		reader.Comment = options.Comment
+	}
+
+	r := &CSVReader{
+		options: options,
+		reader:  reader,
+		missing: map[string]bool{},
+		columns: options.Columns,
+		fields:  -1,
+	}
+
+	for _, token := range options.Missing {
+		r.missing[token] = true
+	}
+
+	if len(options.ColumnNames) > 0 && !options.Header {
+		return nil, errors.New("gosom: column names require a header")
+	}
+
+	if !options.Header {
+		return r, nil
+	}
+
+	record, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("gosom: missing header")
+	} else if err != nil {
+		return nil, err
+	}
+
+	r.fields = len(record)
+	header := make([]string, len(record))
+	for i, name := range record {
+		header[i] = strings.TrimSpace(name)
+	}
+
+	r.header = header
+
+	if len(options.ColumnNames) > 0 {
+		r.columns = nil
+
+		for _, name := range options.ColumnNames {
+			index := indexOf(header, name)
+			if index < 0 {
+				return nil, fmt.Errorf("gosom: unknown column %q", name)
+			}
+
+			r.columns = append(r.columns, index)
+		}
+	}
+
+	if r.columns == nil {
+		r.names = header
+	} else {
+		for _, column := range r.columns {
+			if column < 0 || column >= len(header) {
+				return nil, fmt.Errorf("gosom: column %d out of range", column)
+			}
+
+			r.names = append(r.names, header[column])
+		}
+	}
+
+	return r, nil
+}
+
+// Names returns the names of the selected columns if a header was read.
+func (r *CSVReader) Names() []string {
+	return r.names
+}
+
+// Header returns the names of all columns if a header was read.
+func (r *CSVReader) Header() []string {
+	return r.header
+}
+
+// Record returns the fields of the last row that was read including the
+// columns that are not selected. It is only valid until the next call to Read.
+func (r *CSVReader) Record() []string {
+	return r.record
+}
+
+// Read returns the values of the next row or io.EOF if there are no more
+// rows. All rows must have the same number of fields as the first row or the
+// header.
+func (r *CSVReader) Read() ([]float64, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	r.record = record
+	line, _ := r.reader.FieldPos(0)
+
+	if r.fields < 0 {
+		r.fields = len(record)
+	} else if len(record) != r.fields {
+		return nil, &CSVError{
+			Row: line,
+			Err: fmt.Errorf("expected %d fields but got %d", r.fields, len(record)),
+		}
+	}
+
+	columns := r.columns
+	count := len(record)
+	if columns != nil {
+		count = len(columns)
+	}
+
+	values := make([]float64, count)
+
+	for i := range values {
+		column := i
+		if columns != nil {
+			column = columns[i]
+
+			if column < 0 || column >= len(record) {
+				return nil, &CSVError{
+					Row: line,
+					Err: fmt.Errorf("column %d out of range", column),
+				}
+			}
+		}
+
+		values[i], err = r.parse(record[column])
+		if err != nil {
+			return nil, &CSVError{
+				Row:    line,
+				Column: column + 1,
+				Value:  record[column],
+				Err:    err,
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// parse returns the value of the field or NaN if it is missing.
+func (r *CSVReader) parse(field string) (float64, error) {
+	field = strings.TrimSpace(field)
+
+	if r.missing[field] {
+		return math.NaN(), nil
+	}
+
+	f, err := strconv.ParseFloat(field, 64)
+	if err != nil {
+		if r.options.Strict {
+			return 0, ErrInvalidValue
+		}
+
+		return math.NaN(), nil
+	}
+
+	return f, nil
+}
+
+// LoadMatrixFromCSVWithOptions reads CSV data row by row using the options
+// and returns a new matrix and the names of the selected columns if a header
+// was read.
+func LoadMatrixFromCSVWithOptions(source io.Reader, options CSVOptions) (*Matrix, []string, error) {
+	reader, err := NewCSVReader(source, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var values [][]float64
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, err
+		}
+
+		values = append(values, row)
+	}
+
+	if len(values) == 0 {
+		return nil, nil, errors.New("gosom: no rows")
+	}
+
+	return NewMatrix(values), reader.Names(), nil
+}
+
+func indexOf(list []string, item string) int {
+	for i, value := range list {
+		if value == item {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,117 @@
+package gosom
+
+import (
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVReader(t *testing.T) {
+	source := "a;b;c\n# comment\n1;2;3\n4; NA ;6\n"
+
+	r, err := NewCSVReader(strings.NewReader(source), CSVOptions{
+		Delimiter: ';',
+		Comment:   '#',
+		Header:    true,
+		Missing:   []string{"NA"},
+		Strict:    true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, r.Names())
+	assert.Equal(t, []string{"a", "b", "c"}, r.Header())
+
+	row, err := r.Read()
+	require.NoError(t, err)
+	assert.Equal(t, []float64{1, 2, 3}, row)
+	assert.Equal(t, []string{"1", "2", "3"}, r.Record())
+
+	row, err = r.Read()
+	require.NoError(t, err)
+	assert.Equal(t, 4.0, row[0])
+	assert.True(t, math.IsNaN(row[1]))
+	assert.Equal(t, 6.0, row[2])
+
+	_, err = r.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestLoadMatrixFromCSVWithOptionsColumns(t *testing.T) {
+	source := "a,b,c\n1,2,3\n4,5,6\n"
+
+	m, names, err := LoadMatrixFromCSVWithOptions(strings.NewReader(source), CSVOptions{
+		Header:  true,
+		Columns: []int{2, 0},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "a"}, names)
+	assert.Equal(t, [][]float64{{3, 1}, {6, 4}}, m.Data)
+
+	m, names, err = LoadMatrixFromCSVWithOptions(strings.NewReader(source), CSVOptions{
+		Header:      true,
+		ColumnNames: []string{"b"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, names)
+	assert.Equal(t, [][]float64{{2}, {5}}, m.Data)
+
+	_, _, err = LoadMatrixFromCSVWithOptions(strings.NewReader(source), CSVOptions{
+		Header:      true,
+		ColumnNames: []string{"d"},
+	})
+	assert.Error(t, err)
+
+	_, _, err = LoadMatrixFromCSVWithOptions(strings.NewReader(source), CSVOptions{
+		ColumnNames: []string{"a"},
+	})
+	assert.Error(t, err)
+
+	_, _, err = LoadMatrixFromCSVWithOptions(strings.NewReader(source), CSVOptions{
+		Header:  true,
+		Columns: []int{3},
+	})
+	assert.Error(t, err)
+}
+
+func TestLoadMatrixFromCSVWithOptionsStrict(t *testing.T) {
+	source := "1,2\n\"1,000\",3\n"
+
+	m, _, err := LoadMatrixFromCSVWithOptions(strings.NewReader(source), CSVOptions{})
+	require.NoError(t, err)
+	assert.True(t, math.IsNaN(m.Data[1][0]))
+
+	_, _, err = LoadMatrixFromCSVWithOptions(strings.NewReader(source), CSVOptions{
+		Strict: true,
+	})
+	assert.EqualError(t, err, `gosom: row 2, column 1: invalid value "1,000"`)
+
+	var csvError *CSVError
+	require.True(t, errors.As(err, &csvError))
+	assert.Equal(t, 2, csvError.Row)
+	assert.Equal(t, 1, csvError.Column)
+	assert.True(t, errors.Is(err, ErrInvalidValue))
+
+	m, _, err = LoadMatrixFromCSVWithOptions(strings.NewReader("1,\n2,3\n"), CSVOptions{
+		Missing: []string{""},
+		Strict:  true,
+	})
+	require.NoError(t, err)
+	assert.True(t, math.IsNaN(m.Data[0][1]))
+}
+
+func TestLoadMatrixFromCSVWithOptionsRagged(t *testing.T) {
+	_, _, err := LoadMatrixFromCSVWithOptions(strings.NewReader("1,2\n3,4\n5\n"), CSVOptions{})
+	assert.EqualError(t, err, "gosom: row 3: expected 2 fields but got 1")
+
+	_, _, err = LoadMatrixFromCSVWithOptions(strings.NewReader(""), CSVOptions{})
+	assert.Error(t, err)
+
+	_, _, err = LoadMatrixFromCSVWithOptions(strings.NewReader(""), CSVOptions{
+		Header: true,
+	})
+	assert.Error(t, err)
+}
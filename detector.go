@@ -0,0 +1,102 @@
+package gosom
+
+import (
+	"errors"
+	"math"
+)
+
+// detectorEpsilon is the smallest node error used to normalize distances.
+const detectorEpsilon = 1e-9
+
+// A Detector flags inputs that are not well represented by a SOM. The score
+// of an input is the distance to its best matching node. If Normalized is set
+// the distance is divided by the mean distance of the reference data that was
+// mapped to the same node.
+type Detector struct {
+	SOM        *SOM `json:"-"`
+	Normalized bool
+	Errors     []float64
+	Threshold  float64
+}
+
+// NewDetector returns a new Detector and stores it in the SOM so that it is
+// saved along with the map.
+func NewDetector(som *SOM, normalized bool) *Detector {
+	d := &Detector{
+		SOM:        som,
+		Normalized: normalized,
+	}
+
+	som.Detector = d
+
+	return d
+}
+
+// Calibrate calculates the node errors from the reference data and sets the
+// threshold to the percentile p [0..100] of the reference scores. Nodes
+// without hits or with an error of zero get the mean error of all nodes.
+func (d *Detector) Calibrate(data *Matrix, p float64) error {
+	if data.Rows == 0 {
+		return errors.New("gosom: no reference data")
+	}
+
+	sums := make([]float64, len(d.SOM.Nodes))
+	counts := make([]int, len(d.SOM.Nodes))
+	total := 0.0
+
+	for _, row := range data.Data {
+		node := d.SOM.Closest(row)
+		i := node.Y()*d.SOM.Width + node.X()
+		distance := d.SOM.D(row, node.Weights)
+
+		sums[i] += distance
+		counts[i]++
+		total += distance
+	}
+
+	// nodes without hits or errors get the overall mean error
+	d.Errors = make([]float64, len(d.SOM.Nodes))
+
+	for i := range d.Errors {
+		if counts[i] > 0 && sums[i] > 0 {
+			d.Errors[i] = sums[i] / float64(counts[i])
+		} else {
+			d.Errors[i] = total / float64(data.Rows)
+		}
+	}
+
+	scores := make([]float64, data.Rows)
+
+	for i, row := range data.Data {
+		scores[i] = d.Score(row)
+	}
+
+	d.Threshold = percentile(scores, p)
+
+	return nil
+}
+
+// CalibrateContamination calibrates the detector so that the specified rate
+// [0..1] of the reference data is flagged as anomalous.
+func (d *Detector) CalibrateContamination(data *Matrix, rate float64) error {
+	return d.Calibrate(data, 100.0*(1.0-rate))
+}
+
+// Score returns the anomaly score for the input. Normalized scores divide by
+// at least a small epsilon so that all scores are ratios.
+func (d *Detector) Score(input []float64) float64 {
+	node := d.SOM.Closest(input)
+	distance := d.SOM.D(input, node.Weights)
+
+	if d.Normalized && len(d.Errors) == len(d.SOM.Nodes) {
+		e := d.Errors[node.Y()*d.SOM.Width+node.X()]
+		return distance / math.Max(e, detectorEpsilon)
+	}
+
+	return distance
+}
+
+// IsAnomaly returns whether the score of the input exceeds the threshold.
+func (d *Detector) IsAnomaly(input []float64) bool {
+	return d.Score(input) > d.Threshold
+}
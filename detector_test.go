@@ -0,0 +1,87 @@
+package gosom
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func detectorSOM() *SOM {
+	som := NewSOM(2, 1)
+	som.Nodes = NewLattice(2, 1, 1)
+	som.Nodes[0].Weights[0] = 0.0
+	som.Nodes[1].Weights[0] = 10.0
+
+	return som
+}
+
+var detectorData = NewMatrix([][]float64{
+	{0.1}, {0.2}, {0.3}, {0.4}, {9.0}, {11.0},
+})
+
+func TestDetector(t *testing.T) {
+	som := detectorSOM()
+	d := NewDetector(som, false)
+	assert.Equal(t, d, som.Detector)
+
+	d.Calibrate(detectorData, 100)
+	assert.Equal(t, []float64{0.25, 1.0}, d.Errors)
+	assert.Equal(t, 1.0, d.Threshold)
+
+	assert.InDelta(t, 0.5, d.Score([]float64{0.5}), 0.0001)
+	assert.False(t, d.IsAnomaly([]float64{0.5}))
+	assert.True(t, d.IsAnomaly([]float64{5.0}))
+}
+
+func TestDetectorNormalized(t *testing.T) {
+	d := NewDetector(detectorSOM(), true)
+	d.Calibrate(detectorData, 100)
+	assert.Equal(t, 1.6, d.Threshold)
+
+	assert.Equal(t, 2.0, d.Score([]float64{0.5}))
+	assert.True(t, d.IsAnomaly([]float64{0.5}))
+	assert.False(t, d.IsAnomaly([]float64{11.5}))
+}
+
+func TestDetectorZeroErrors(t *testing.T) {
+	d := NewDetector(detectorSOM(), true)
+	assert.NoError(t, d.Calibrate(NewMatrix([][]float64{{0.0}, {9.0}, {11.0}}), 100))
+	assert.Equal(t, []float64{2.0 / 3.0, 1.0}, d.Errors)
+	assert.InDelta(t, 0.75, d.Score([]float64{0.5}), 0.0001)
+
+	d = NewDetector(detectorSOM(), true)
+	assert.NoError(t, d.Calibrate(NewMatrix([][]float64{{0.0}, {10.0}}), 100))
+	assert.Equal(t, 0.0, d.Threshold)
+	assert.True(t, d.IsAnomaly([]float64{0.5}))
+
+	assert.Error(t, d.Calibrate(&Matrix{}, 100))
+}
+
+func TestDetectorContamination(t *testing.T) {
+	d := NewDetector(detectorSOM(), false)
+	d.CalibrateContamination(detectorData, 0.5)
+
+	flagged := 0
+	for _, row := range detectorData.Data {
+		if d.IsAnomaly(row) {
+			flagged++
+		}
+	}
+
+	assert.Equal(t, 3, flagged)
+}
+
+func TestDetectorJSON(t *testing.T) {
+	som := detectorSOM()
+	NewDetector(som, true).Calibrate(detectorData, 90)
+
+	var buf bytes.Buffer
+	assert.NoError(t, som.SaveAsJSON(&buf))
+
+	loaded, err := LoadSOMFromJSON(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, som.Detector.Threshold, loaded.Detector.Threshold)
+	assert.Equal(t, som.Detector.Errors, loaded.Detector.Errors)
+	assert.Equal(t, loaded, loaded.Detector.SOM)
+}
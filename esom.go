@@ -0,0 +1,392 @@
+package gosom
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The column types of ESOM .lrn files.
+const (
+	esomIgnore = 0
+	esomData   = 1
+	esomKey    = 9
+)
+
+// A BestMatch associates a data row identified by its key with a node.
+type BestMatch struct {
+	Key int
+	X   int
+	Y   int
+}
+
+// LoadMatrixFromLRN reads data in the ESOM .lrn format from source and returns
+// a matrix with all data columns and their names. Key and ignored columns are
+// skipped.
+func LoadMatrixFromLRN(source io.Reader) (*Matrix, []string, error) {
+	headers, lines, err := readESOM(source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(headers) < 3 {
+		return nil, nil, errors.New("gosom: invalid lrn header")
+	}
+
+	types := make([]int, len(headers[2]))
+	for i, field := range headers[2] {
+		types[i], err = strconv.Atoi(field)
+		if err != nil {
+			return nil, nil, errors.New("gosom: invalid lrn column types")
+		}
+	}
+
+	var names []string
+	if len(headers) > 3 && len(headers[3]) == len(types) {
+		for i, name := range headers[3] {
+			if types[i] == esomData {
+				names = append(names, name)
+			}
+		}
+	}
+
+	if len(lines) == 0 {
+		return nil, nil, errors.New("gosom: no rows")
+	}
+
+	values := make([][]float64, len(lines))
+
+	for i, line := range lines {
+		if len(line) < len(types) {
+			return nil, nil, fmt.Errorf("gosom: expected %d columns but got %d", len(types), len(line))
+		}
+
+		for j, t := range types {
+			if t != esomData {
+				continue
+			}
+
+			value, err := parseESOMValue(line[j])
+			if err != nil {
+				return nil, nil, err
+			}
+
+			values[i] = append(values[i], value)
+		}
+	}
+
+	return NewMatrix(values), names, nil
+}
+
+// SaveAsLRN writes the matrix in the ESOM .lrn format to destination. The rows
+// are keyed starting at one. Columns are named C1, C2, ... if no names are
+// provided.
+func (m *Matrix) SaveAsLRN(destination io.Writer, names []string) error {
+	return m.SaveAsLRNWithIgnored(destination, names, nil)
+}
+
+// SaveAsLRNWithIgnored writes the matrix like SaveAsLRN but marks the ignored
+// columns (e.g. class labels) so that the ESOM tools do not train on them.
+func (m *Matrix) SaveAsLRNWithIgnored(destination io.Writer, names []string, ignored []int) error {
+	w := bufio.NewWriter(destination)
+
+	types := []string{strconv.Itoa(esomKey)}
+	header := []string{"Key"}
+
+	for i := 0; i < m.Columns; i++ {
+		t := esomData
+		for _, column := range ignored {
+			if column == i {
+				t = esomIgnore
+			}
+		}
+
+		types = append(types, strconv.Itoa(t))
+
+		if i < len(names) {
+			header = append(header, names[i])
+		} else {
+			header = append(header, fmt.Sprintf("C%d", i+1))
+		}
+	}
+
+	fmt.Fprintf(w, "%% %d\n", m.Rows)
+	fmt.Fprintf(w, "%% %d\n", m.Columns+1)
+	fmt.Fprintf(w, "%% %s\n", strings.Join(types, "\t"))
+	fmt.Fprintf(w, "%% %s\n", strings.Join(header, "\t"))
+
+	for i, row := range m.Data {
+		w.WriteString(strconv.Itoa(i + 1))
+
+		for _, value := range row {
+			w.WriteByte('\t')
+			w.WriteString(formatESOMValue(value))
+		}
+
+		w.WriteByte('\n')
+	}
+
+	return w.Flush()
+}
+
+// LoadSOMFromWTS reads the weights of a SOM in the ESOM .wts format from
+// source. The grid rows correspond to the height and the columns to the width
+// of the SOM.
+func LoadSOMFromWTS(source io.Reader) (*SOM, error) {
+	headers, lines, err := readESOM(source)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(headers) < 2 || len(headers[0]) < 2 || len(headers[1]) < 1 {
+		return nil, errors.New("gosom: invalid wts header")
+	}
+
+	height, err1 := strconv.Atoi(headers[0][0])
+	width, err2 := strconv.Atoi(headers[0][1])
+	dimensions, err3 := strconv.Atoi(headers[1][0])
+	if err1 != nil || err2 != nil || err3 != nil || height <= 0 || width <= 0 || dimensions <= 0 {
+		return nil, errors.New("gosom: invalid wts header")
+	}
+
+	// check the sizes against the lines before multiplying or allocating
+	if width > len(lines) || height > len(lines) || width*height != len(lines) {
+		return nil, fmt.Errorf("gosom: expected %dx%d nodes but got %d", width, height, len(lines))
+	}
+
+	for _, line := range lines {
+		if len(line) < dimensions {
+			return nil, fmt.Errorf("gosom: expected %d weights but got %d", dimensions, len(line))
+		}
+	}
+
+	som := NewSOM(width, height)
+	som.Nodes = NewLattice(width, height, dimensions)
+
+	for i, node := range som.Nodes {
+		for j := range node.Weights {
+			node.Weights[j], err = parseESOMValue(lines[i][j])
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return som, nil
+}
+
+// SaveAsWTS writes the weights of the SOM in the ESOM .wts format to
+// destination.
+func (som *SOM) SaveAsWTS(destination io.Writer) error {
+	w := bufio.NewWriter(destination)
+
+	fmt.Fprintf(w, "%% %d %d\n", som.Height, som.Width)
+	fmt.Fprintf(w, "%% %d\n", som.Dimensions())
+
+	for _, node := range som.Nodes {
+		writeESOMValues(w, node.Weights)
+	}
+
+	return w.Flush()
+}
+
+// BestMatches returns the best matching nodes of the rows in data. The rows are
+// keyed starting at one.
+func (som *SOM) BestMatches(data *Matrix) []BestMatch {
+	matches := make([]BestMatch, data.Rows)
+
+	for i, row := range data.Data {
+		node := som.Closest(row)
+
+		matches[i] = BestMatch{
+			Key: i + 1,
+			X:   node.X(),
+			Y:   node.Y(),
+		}
+	}
+
+	return matches
+}
+
+// LoadBestMatchesFromBM reads best matches in the ESOM .bm format from source.
+// The one based grid positions are converted to node coordinates.
+func LoadBestMatchesFromBM(source io.Reader) ([]BestMatch, error) {
+	_, lines, err := readESOM(source)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]BestMatch, len(lines))
+
+	for i, line := range lines {
+		if len(line) < 3 {
+			return nil, errors.New("gosom: invalid best match")
+		}
+
+		key, err1 := strconv.Atoi(line[0])
+		row, err2 := strconv.Atoi(line[1])
+		column, err3 := strconv.Atoi(line[2])
+		if err1 != nil || err2 != nil || err3 != nil {
+			return nil, errors.New("gosom: invalid best match")
+		}
+
+		matches[i] = BestMatch{
+			Key: key,
+			X:   column - 1,
+			Y:   row - 1,
+		}
+	}
+
+	return matches, nil
+}
+
+// SaveBestMatchesAsBM writes the best matches in the ESOM .bm format to
+// destination.
+func (som *SOM) SaveBestMatchesAsBM(destination io.Writer, matches []BestMatch) error {
+	w := bufio.NewWriter(destination)
+
+	fmt.Fprintf(w, "%% %d %d\n", som.Height, som.Width)
+	fmt.Fprintf(w, "%% %d\n", len(matches))
+
+	for _, match := range matches {
+		fmt.Fprintf(w, "%d\t%d\t%d\n", match.Key, match.Y+1, match.X+1)
+	}
+
+	return w.Flush()
+}
+
+// LoadUMatrixFromUMX reads heights in the ESOM .umx format from source and
+// returns them as a matrix with one row per grid row.
+func LoadUMatrixFromUMX(source io.Reader) (*Matrix, error) {
+	_, lines, err := readESOM(source)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(lines) == 0 {
+		return nil, errors.New("gosom: no rows")
+	}
+
+	values := make([][]float64, len(lines))
+
+	for i, line := range lines {
+		values[i] = make([]float64, len(line))
+
+		for j, field := range line {
+			values[i][j], err = parseESOMValue(field)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return NewMatrix(values), nil
+}
+
+// SaveUMatrixAsUMX writes the U-Matrix of the SOM in the ESOM .umx format to
+// destination.
+func (som *SOM) SaveUMatrixAsUMX(destination io.Writer) error {
+	w := bufio.NewWriter(destination)
+	heights := som.UMatrix()
+
+	for y := 0; y < som.Height; y++ {
+		writeESOMValues(w, heights[y*som.Width:(y+1)*som.Width])
+	}
+
+	return w.Flush()
+}
+
+// LoadClassesFromCLS reads class labels in the ESOM .cls format from source
+// and returns them in the order of the file.
+func LoadClassesFromCLS(source io.Reader) ([]int, error) {
+	_, lines, err := readESOM(source)
+	if err != nil {
+		return nil, err
+	}
+
+	classes := make([]int, len(lines))
+
+	for i, line := range lines {
+		if len(line) < 2 {
+			return nil, errors.New("gosom: invalid class")
+		}
+
+		classes[i], err = strconv.Atoi(line[1])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return classes, nil
+}
+
+// SaveClassesAsCLS writes the class labels in the ESOM .cls format to
+// destination. The rows are keyed starting at one.
+func SaveClassesAsCLS(destination io.Writer, classes []int) error {
+	w := bufio.NewWriter(destination)
+
+	fmt.Fprintf(w, "%% %d\n", len(classes))
+
+	for i, class := range classes {
+		fmt.Fprintf(w, "%d\t%d\n", i+1, class)
+	}
+
+	return w.Flush()
+}
+
+// readESOM returns the fields of the header lines that start with "%" and of
+// the remaining lines that are not empty or comments.
+func readESOM(source io.Reader) ([][]string, [][]string, error) {
+	var headers, lines [][]string
+
+	scanner := bufio.NewScanner(source)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(text, "%") {
+			headers = append(headers, strings.Fields(text[1:]))
+			continue
+		}
+
+		lines = append(lines, strings.Fields(text))
+	}
+
+	return headers, lines, scanner.Err()
+}
+
+// parseESOMValue returns the value of the field. Missing values are written as
+// NaN, which is parsed like any other value.
+func parseESOMValue(field string) (float64, error) {
+	f, err := strconv.ParseFloat(field, 64)
+	if err != nil {
+		return 0, fmt.Errorf("gosom: invalid value %q", field)
+	}
+
+	return f, nil
+}
+
+func formatESOMValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// writeESOMValues writes the values as a tab separated line.
+func writeESOMValues(w *bufio.Writer, values []float64) {
+	for i, value := range values {
+		if i > 0 {
+			w.WriteByte('\t')
+		}
+
+		w.WriteString(formatESOMValue(value))
+	}
+
+	w.WriteByte('\n')
+}
@@ -0,0 +1,124 @@
+package gosom
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRN(t *testing.T) {
+	lrn := `# comment
+% 2
+% 4
+% 9	1	0	1
+% Key	a	b	c
+1	0.5	7	1
+2	NaN	8	2
+`
+
+	m, names, err := LoadMatrixFromLRN(strings.NewReader(lrn))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c"}, names)
+	assert.Equal(t, 2, m.Columns)
+	assert.Equal(t, 0.5, m.Data[0][0])
+	assert.True(t, math.IsNaN(m.Data[1][0]))
+	assert.Equal(t, 2.0, m.Data[1][1])
+
+	var buf bytes.Buffer
+	require.NoError(t, m.SaveAsLRN(&buf, []string{"a"}))
+	assert.Equal(t, "% 2\n% 3\n% 9\t1\t1\n% Key\ta\tC2\n1\t0.5\t1\n2\tNaN\t2\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, m.SaveAsLRNWithIgnored(&buf, []string{"a", "c"}, []int{1}))
+	assert.Equal(t, "% 2\n% 3\n% 9\t1\t0\n% Key\ta\tc\n1\t0.5\t1\n2\tNaN\t2\n", buf.String())
+
+	m, names, err = LoadMatrixFromLRN(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, names)
+	assert.Equal(t, 1, m.Columns)
+
+	_, _, err = LoadMatrixFromLRN(strings.NewReader("% 0\n% 2\n% 9\t1\n% Key\ta\n"))
+	assert.Error(t, err)
+
+	_, _, err = LoadMatrixFromLRN(strings.NewReader("% 1\n% 2\n% 9\t1\n% Key\ta\n1\tfoo\n"))
+	assert.Error(t, err)
+}
+
+func TestWTS(t *testing.T) {
+	som := NewSOM(3, 2)
+	som.InitializeWithRandomValues(NewMatrix([][]float64{{0, 0}, {1, 1}}))
+
+	var buf bytes.Buffer
+	require.NoError(t, som.SaveAsWTS(&buf))
+	assert.True(t, strings.HasPrefix(buf.String(), "% 2 3\n% 2\n"))
+
+	loaded, err := LoadSOMFromWTS(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, som.Nodes, loaded.Nodes)
+
+	_, err = LoadSOMFromWTS(strings.NewReader("% 2 2\n% 1\n0\n"))
+	assert.Error(t, err)
+
+	_, err = LoadSOMFromWTS(strings.NewReader("% -1 -1\n% 1\n0\n"))
+	assert.Error(t, err)
+
+	_, err = LoadSOMFromWTS(strings.NewReader("% 1 1\n% -1\n0\n"))
+	assert.Error(t, err)
+
+	_, err = LoadSOMFromWTS(strings.NewReader("% 1 1\n% 1000000000000\n0\n"))
+	assert.Error(t, err)
+
+	_, err = LoadSOMFromWTS(strings.NewReader("% 1 1\n% 1\nfoo\n"))
+	assert.Error(t, err)
+}
+
+func TestBM(t *testing.T) {
+	som := NewSOM(2, 2)
+	som.InitializeWithZeroes(1)
+	som.N(1, 1).Weights[0] = 1
+
+	matches := som.BestMatches(NewMatrix([][]float64{{1}}))
+	assert.Equal(t, []BestMatch{{Key: 1, X: 1, Y: 1}}, matches)
+
+	var buf bytes.Buffer
+	require.NoError(t, som.SaveBestMatchesAsBM(&buf, matches))
+	assert.Equal(t, "% 2 2\n% 1\n1\t2\t2\n", buf.String())
+
+	loaded, err := LoadBestMatchesFromBM(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, matches, loaded)
+}
+
+func TestUMX(t *testing.T) {
+	som := NewSOM(2, 1)
+	som.InitializeWithZeroes(1)
+	som.Nodes[1].Weights[0] = 2
+
+	var buf bytes.Buffer
+	require.NoError(t, som.SaveUMatrixAsUMX(&buf))
+	assert.Equal(t, "2\t2\n", buf.String())
+
+	heights, err := LoadUMatrixFromUMX(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, [][]float64{{2, 2}}, heights.Data)
+
+	_, err = LoadUMatrixFromUMX(strings.NewReader(""))
+	assert.Error(t, err)
+
+	_, err = LoadUMatrixFromUMX(strings.NewReader("1\tfoo\n"))
+	assert.Error(t, err)
+}
+
+func TestCLS(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, SaveClassesAsCLS(&buf, []int{1, 3}))
+	assert.Equal(t, "% 2\n1\t1\n2\t3\n", buf.String())
+
+	classes, err := LoadClassesFromCLS(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 3}, classes)
+}
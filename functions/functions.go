@@ -5,6 +5,10 @@ import "math"
 // A DistanceFunction calculates the distance between to points.
 type DistanceFunction func(from, to []float64) float64
 
+// A DistanceFunction32 calculates the distance between to points in single
+// precision.
+type DistanceFunction32 func(from, to []float32) float32
+
 // A CoolingFunction calculates the cooling alpha [1..0] for an input value [0..1].
 type CoolingFunction func(progress float64) float64
 
@@ -47,6 +51,98 @@ func ManhattanDistance(from, to []float64) float64 {
 	return d
 }
 
+// CosineDistance returns the cosine distance between two points. The distance
+// to a zero vector is one.
+//
+// Note: Dimensions that include NaNs are ignored.
+func CosineDistance(from, to []float64) float64 {
+	dot, a, b := 0.0, 0.0, 0.0
+	l := min(len(from), len(to))
+
+	for i := 0; i < l; i++ {
+		if math.IsNaN(from[i]) || math.IsNaN(to[i]) {
+			continue
+		}
+
+		dot += from[i] * to[i]
+		a += from[i] * from[i]
+		b += to[i] * to[i]
+	}
+
+	if a == 0 || b == 0 {
+		return 1.0
+	}
+
+	return 1.0 - dot/(math.Sqrt(a)*math.Sqrt(b))
+}
+
+// EuclideanDistance32 returns the euclidean distance between two points in
+// single precision.
+//
+// Note: Dimensions that include NaNs are ignored.
+func EuclideanDistance32(from, to []float32) float32 {
+	var d float32
+	l := min(len(from), len(to))
+
+	for i := 0; i < l; i++ {
+		if from[i] != from[i] || to[i] != to[i] {
+			continue
+		}
+
+		d += (from[i] - to[i]) * (from[i] - to[i])
+	}
+
+	return float32(math.Sqrt(float64(d)))
+}
+
+// ManhattanDistance32 returns the manhattan distance between two points in
+// single precision.
+//
+// Note: Dimensions that include NaNs are ignored.
+func ManhattanDistance32(from, to []float32) float32 {
+	var d float32
+	l := min(len(from), len(to))
+
+	for i := 0; i < l; i++ {
+		if from[i] != from[i] || to[i] != to[i] {
+			continue
+		}
+
+		if to[i] > from[i] {
+			d += to[i] - from[i]
+		} else {
+			d += from[i] - to[i]
+		}
+	}
+
+	return d
+}
+
+// CosineDistance32 returns the cosine distance between two points in single
+// precision. The distance to a zero vector is one.
+//
+// Note: Dimensions that include NaNs are ignored.
+func CosineDistance32(from, to []float32) float32 {
+	var dot, a, b float32
+	l := min(len(from), len(to))
+
+	for i := 0; i < l; i++ {
+		if from[i] != from[i] || to[i] != to[i] {
+			continue
+		}
+
+		dot += from[i] * to[i]
+		a += from[i] * from[i]
+		b += to[i] * to[i]
+	}
+
+	if a == 0 || b == 0 {
+		return 1.0
+	}
+
+	return 1.0 - dot/float32(math.Sqrt(float64(a))*math.Sqrt(float64(b)))
+}
+
 // LinearCooling returns the linear cooling factor for progress.
 func LinearCooling(progress float64) float64 {
 	return 1.0 - progress
@@ -103,45 +199,101 @@ func EpanechicovNeighborhood(distance float64) float64 {
 	return math.Max(0.0, 1.0-(distance*distance))
 }
 
-// CoolingFactor returns the cooling factors based on the selected coolingFunction.
-func CoolingFactor(coolingFunction string, progress float64) float64 {
+// LookupCooling returns the cooling function with the name or nil if it is
+// unknown.
+func LookupCooling(coolingFunction string) CoolingFunction {
 	switch coolingFunction {
 	case "linear":
-		return LinearCooling(progress)
+		return LinearCooling
 	case "soft":
-		return SoftCooling(progress)
+		return SoftCooling
 	case "medium":
-		return MediumCooling(progress)
+		return MediumCooling
 	case "hard":
-		return HardCooling(progress)
+		return HardCooling
 	}
 
-	return 0.0
+	return nil
 }
 
-// Distance returns the distance between two points based on the selected distanceFunction.
-func Distance(distanceFunction string, from, to []float64) float64 {
+// LookupDistance returns the distance function with the name or nil if it is
+// unknown.
+func LookupDistance(distanceFunction string) DistanceFunction {
 	switch distanceFunction {
 	case "euclidean":
-		return EuclideanDistance(from, to)
+		return EuclideanDistance
 	case "manhattan":
-		return ManhattanDistance(from, to)
+		return ManhattanDistance
+	case "cosine":
+		return CosineDistance
 	}
 
-	return 0.0
+	return nil
 }
 
-// NeighborhoodInfluence returns the influence of the distance based on the selected neighborhoodFunction.
-func NeighborhoodInfluence(neighborhoodFunction string, distance float64) float64 {
+// LookupDistance32 returns the single precision distance function with the
+// name or nil if it is unknown.
+func LookupDistance32(distanceFunction string) DistanceFunction32 {
+	switch distanceFunction {
+	case "euclidean":
+		return EuclideanDistance32
+	case "manhattan":
+		return ManhattanDistance32
+	case "cosine":
+		return CosineDistance32
+	}
+
+	return nil
+}
+
+// LookupNeighborhood returns the neighborhood function with the name or nil if
+// it is unknown.
+func LookupNeighborhood(neighborhoodFunction string) NeighborhoodFunction {
 	switch neighborhoodFunction {
 	case "bubble":
-		return BubbleNeighborhood(distance)
+		return BubbleNeighborhood
 	case "cone":
-		return ConeNeighborhood(distance)
+		return ConeNeighborhood
 	case "gaussian":
-		return GaussianNeighborhood(distance)
+		return GaussianNeighborhood
 	case "epanechicov":
-		return EpanechicovNeighborhood(distance)
+		return EpanechicovNeighborhood
+	}
+
+	return nil
+}
+
+// CoolingFactor returns the cooling factors based on the selected coolingFunction.
+func CoolingFactor(coolingFunction string, progress float64) float64 {
+	if f := LookupCooling(coolingFunction); f != nil {
+		return f(progress)
+	}
+
+	return 0.0
+}
+
+// Distance returns the distance between two points based on the selected distanceFunction.
+func Distance(distanceFunction string, from, to []float64) float64 {
+	if f := LookupDistance(distanceFunction); f != nil {
+		return f(from, to)
+	}
+
+	return 0.0
+}
+
+// Distance32 returns the single precision distance between two points based on the selected distanceFunction.
+func Distance32(distanceFunction string, from, to []float32) float32 {
+	if f := LookupDistance32(distanceFunction); f != nil {
+		return f(from, to)
+	}
+
+	return 0.0
+}
+
+// NeighborhoodInfluence returns the influence of the distance based on the selected neighborhoodFunction.
+func NeighborhoodInfluence(neighborhoodFunction string, distance float64) float64 {
+	if f := LookupNeighborhood(neighborhoodFunction); f != nil {
+		return f(distance)
 	}
 
 	return 0.0
@@ -47,6 +47,41 @@ func TestManhattanDistance(t *testing.T) {
 	))
 }
 
+func TestCosineDistance(t *testing.T) {
+	assert.InDelta(t, 0.0, Distance(
+		"cosine",
+		[]float64{1.0, 1.0},
+		[]float64{2.0, 2.0},
+	), 1e-12)
+
+	assert.Equal(t, 1.0, Distance(
+		"cosine",
+		[]float64{0.0, 1.0},
+		[]float64{1.0, 0.0},
+	))
+
+	assert.Equal(t, 1.0, Distance(
+		"cosine",
+		[]float64{1.0, 1.0},
+		[]float64{0.0, 0.0},
+	))
+}
+
+func TestDistance32(t *testing.T) {
+	from := []float64{0.5, math.NaN(), 2.0, -1.0}
+	to := []float64{1.5, 1.0, 0.25, 3.0}
+
+	from32 := []float32{0.5, float32(math.NaN()), 2.0, -1.0}
+	to32 := []float32{1.5, 1.0, 0.25, 3.0}
+
+	for _, name := range []string{"euclidean", "manhattan", "cosine"} {
+		assert.InDelta(t, Distance(name, from, to), float64(Distance32(name, from32, to32)), 1e-6, name)
+	}
+
+	assert.Equal(t, float32(1.0), Distance32("cosine", []float32{1.0}, []float32{0.0}))
+	assert.Equal(t, float32(0.0), Distance32("foo", from32, to32))
+}
+
 func TestCoolingFunctions(t *testing.T) {
 	assert.True(t, CoolingFactor("linear", 0.0) > 0.95)
 	assert.True(t, CoolingFactor("soft", 0.0) > 0.95)
@@ -71,6 +106,21 @@ func TestNeighborhoodFunctions(t *testing.T) {
 	assert.True(t, NeighborhoodInfluence("epanechicov", 0.5) > 0)
 }
 
+func TestLookup(t *testing.T) {
+	assert.NotNil(t, LookupCooling("linear"))
+	assert.NotNil(t, LookupDistance("cosine"))
+	assert.NotNil(t, LookupDistance32("manhattan"))
+	assert.NotNil(t, LookupNeighborhood("gaussian"))
+
+	assert.Nil(t, LookupCooling("foo"))
+	assert.Nil(t, LookupDistance("foo"))
+	assert.Nil(t, LookupDistance32("foo"))
+	assert.Nil(t, LookupNeighborhood("foo"))
+
+	assert.Equal(t, 0.0, CoolingFactor("foo", 0.5))
+	assert.Equal(t, 0.0, NeighborhoodInfluence("foo", 0.5))
+}
+
 func TestMin(t *testing.T) {
 	assert.Equal(t, 1, min(1, 2))
 	assert.Equal(t, 1, min(2, 1))
@@ -2,6 +2,7 @@ package main
 
 import (
 	"strconv"
+	"strings"
 
 	"github.com/docopt/docopt-go"
 )
@@ -13,6 +14,15 @@ type config struct {
 	interpolate bool
 	plot        bool
 	test        bool
+	impute      bool
+	cluster     bool
+	calibrate   bool
+	detect      bool
+	umatrix     bool
+	convert     bool
+	esom        bool
+	crossval    bool
+	tune        bool
 	functions   bool
 
 	file                 string
@@ -36,25 +46,81 @@ type config struct {
 	prefix               string
 	testDimensions       int
 	quiet                bool
+	output               string
+	imputationMethod     string
+	confidence           string
+	algorithm            string
+	clusters             int
+	linkage              string
+	percentile           float64
+	contamination        float64
+	normalized           bool
+	labels               int
+	colorMap             string
+	legend               bool
+	names                []string
+	topology             string
+	edges                bool
+	pMatrix              bool
+	uStarMatrix          bool
+	format               string
+	labelFile            string
+	allLabels            bool
+	projection           string
+	animation            string
+	interval             int
+	planes               []int
+	seed                 int64
+	checkpoint           int
+	resume               bool
+	idxLabels            string
+	precision            int
+	delimiter            string
+	comment              string
+	header               bool
+	missing              []string
+	strict               bool
+	columns              []int
+	folds                int
+	sizes                []string
+	search               string
+	candidates           int
+	metric               string
+	ratio                float64
+	workers              int
+	neighborhoods        []string
+	coolings             []string
+	stepList             []int
+	learningRates        []float64
+	radii                []float64
 }
 
 func parseConfig() *config {
 	usage := `Self organizing maps for go.
 
 Usage:
-  gosom prepare <file> <data> <width> <height> [-i <im> -d <df> -n <nf> -c <cf>]
-  gosom train <file> <data> [-t <ts> -l <lr> -m <lr> -r <nr> -g <nr>]
+  gosom prepare <file> <data> <width> <height> [-i <im> -d <df> -n <nf> -c <cf> --topology <tp> --names <cn> --seed <sd> --idx-labels <il> --precision <pr> --delimiter <dl> --comment <cc> --header --missing <mv> --strict --columns <cl>]
+  gosom train <file> <data> [-t <ts> -l <lr> -m <lr> -r <nr> -g <nr> --animate <af> --interval <ai> --planes <ap> -s <ns> --colormap <cm> --seed <sd> --checkpoint <ci> --resume --idx-labels <il> --delimiter <dl> --comment <cc> --header --missing <mv> --strict --columns <cl>]
   gosom classify <file> <input>
   gosom interpolate <file> <input> [-w -k <nn>]
-  gosom plot <file> <directory> [-s <ns> -p <fp>]
-  gosom test <file> <data> [-k <nn> -j <td> -q]
+  gosom plot <file> <directory> [<data>] [-s <ns> -p <fp> --labels <lc> --colormap <cm> --legend --names <cn> --pmatrix --ustar --format <ff> --label-file <lf> --all-labels --projection <pm> --delimiter <dl> --comment <cc> --header --missing <mv> --strict --columns <cl>]
+  gosom test <file> <data> [-k <nn> -j <td> -q --idx-labels <il> --delimiter <dl> --comment <cc> --header --missing <mv> --strict --columns <cl>]
+  gosom impute <file> <data> <output> [-e <im> -k <nn> -o <cf> --delimiter <dl> --comment <cc> --header --missing <mv> --strict --columns <cl>]
+  gosom cluster <file> <directory> [-a <ca> -u <nc> -x <lk> -s <ns> -p <fp> --format <ff>]
+  gosom calibrate <file> <data> [--percentile <pc> | --contamination <cr>] [--normalized --delimiter <dl> --comment <cc> --header --missing <mv> --strict --columns <cl>]
+  gosom detect <file> <data> <output> [--delimiter <dl> --comment <cc> --header --missing <mv> --strict --columns <cl>]
+  gosom umatrix <file> <output> [--edges]
+  gosom convert <input> <output> [--delimiter <dl> --comment <cc> --header --missing <mv> --strict --columns <cl>]
+  gosom esom <file> <data> <directory> [-p <fp> --labels <lc> --delimiter <dl> --comment <cc> --header --missing <mv> --strict --columns <cl>]
+  gosom crossval <data> <width> <height> [--folds <fo> --labels <lc> -i <im> -d <df> -n <nf> -c <cf> --topology <tp> --precision <pr> -t <ts> -l <lr> -m <lr> -r <nr> -g <nr> -k <nn> -j <td> --seed <sd> --idx-labels <il> --delimiter <dl> --comment <cc> --header --missing <mv> --strict --columns <cl>]
+  gosom tune <file> <data> <output> [--sizes <sz> --search <sm> --candidates <nc> --metric <tm> --ratio <tr> --workers <wk> --labels <lc> -i <im> -d <df> -n <nf> -c <cf> --topology <tp> --precision <pr> -t <ts> -l <lr> -m <lr> -r <nr> -g <nr> -k <nn> -j <td> -w --seed <sd> --idx-labels <il> --delimiter <dl> --comment <cc> --header --missing <mv> --strict --columns <cl>]
   gosom -f
   gosom -h
   gosom -v
 
 Options:
   -i <im>  Initialization method (random, datapoints) [default: datapoints].
-  -d <df>  Distance function (euclidean, manhattan) [default: euclidean].
+  -d <df>  Distance function (euclidean, manhattan, cosine) [default: euclidean].
   -n <nf>  Neighborhood function (bubble, cone, gaussian, epanechicov) [default: cone].
   -c <cf>  Cooling function (linear, soft, medium, hard) [default: linear].
   -t <ts>  Number of training steps [default: 10000].
@@ -68,9 +134,77 @@ Options:
   -p <fp>  Filename prefix [default: som].
   -j <td>  Number of dimensions to test [default: 1].
   -q       Quiet output.
+  -e <im>  Imputation method (bmu, knn, weighted) [default: bmu].
+  -o <cf>  Write per-cell confidence to file.
+  -a <ca>  Clustering algorithm (kmeans, agglomerative, watershed) [default: kmeans].
+  -u <nc>  Number of clusters [default: 5].
+  -x <lk>  Agglomerative linkage (ward, average) [default: ward].
+  --percentile <pc>     Percentile of reference scores used as threshold [default: 99].
+  --contamination <cr>  Rate of reference data flagged as anomalous.
+  --normalized          Normalize distances by the node errors.
+  --labels <lc>         Column of the data that holds class labels.
+  --colormap <cm>       Color map (gray, viridis, magma, bluered) [default: gray].
+  --legend              Draw a color bar legend.
+  --names <cn>          Comma separated column names stored in the model or used as titles.
+  --topology <tp>       Lattice topology (rectangular, hexagonal) [default: rectangular].
+  --edges               Export the distances between adjacent nodes.
+  --pmatrix             Plot the P-Matrix of the data.
+  --ustar               Plot the U*-Matrix of the data.
+  --format <ff>         Image format (png, svg) [default: png].
+  --label-file <lf>     File with one label per data row drawn on the maps.
+  --all-labels          Draw all labels of a node instead of the most frequent.
+  --projection <pm>     Plot the nodes projected with sammon or mds.
+  --animate <af>        Write an animated GIF of the training to file.
+  --interval <ai>       Number of steps between animation frames [default: 100].
+  --planes <ap>         Comma separated component planes to animate instead of the U-Matrix.
+  --seed <sd>           Seed of the random number generator (random if not set).
+  --checkpoint <ci>     Save the SOM and training state every number of steps.
+  --resume              Resume the training from the last checkpoint.
+  --idx-labels <il>     IDX label file whose labels are appended to the IDX data.
+  --folds <fo>          Number of folds for the cross-validation [default: 5].
+  --sizes <sz>          Comma separated map sizes to tune (e.g. 10x10,20x15) [default: 10x10,20x20].
+  --search <sm>         Search strategy (grid, random) [default: grid].
+  --candidates <nc>     Number of candidates drawn by the random search [default: 20].
+  --metric <tm>         Metric that ranks the candidates (qe, te, interpolation) [default: qe].
+  --ratio <tr>          Share of the data held out for testing [default: 0.2].
+  --workers <wk>        Number of candidates trained in parallel (all cores if 0) [default: 0].
+  --precision <pr>      Precision of the training kernels in bits (64, 32) [default: 64].
+  --delimiter <dl>      Field delimiter of CSV data (e.g. ";" or tab) [default: ,].
+  --comment <cc>        Character that starts comment lines in CSV data.
+  --header              Read the column names from the first row of CSV data.
+  --missing <mv>        Comma separated tokens that mark missing values (e.g. NA,NULL).
+  --strict              Fail on CSV values that are neither numbers nor missing tokens.
+  --columns <cl>        Comma separated indexes of the CSV columns to read.
   -f       Plot functions to current directoy.
   -h       Show help.
-  -v       Show version.`
+  -v       Show version.
+
+SOMs are stored as JSON models with metadata and a checksum. Files ending in
+.bin are stored in the compact binary format without metadata using the
+precision of the SOM. The format of
+existing files is detected automatically. Data can be read from CSV or IDX
+files, which may be gzip compressed. The prepare and train commands also accept
+sparse data in the LIBSVM format (.svm, .libsvm, .svmlight), which is trained
+without expanding the rows.
+
+The convert command converts SOMs between JSON or binary and SOM_PAK codebook
+(.cod) or ESOM weight files (.wts) and data between CSV and SOM_PAK (.dat) or
+ESOM data files (.lrn). The esom command exports a SOM with its data, best
+matches, U-Matrix and classes for the Databionics ESOM tools.
+
+The crossval command prepares, trains and tests a SOM for every fold of the
+data and reports the mean and standard deviation of the test errors and the
+quantization and topographic errors. The folds are stratified by the class
+labels if a label column is set.
+
+The tune command trains a SOM for every combination of the map sizes and the
+comma separated values of -n, -c, -l, -r and -t on one split of the data and
+evaluates it on the held-out rows. The random search trains a random subset of
+the combinations. The candidates are ranked by the quantization error, the
+topographic error or the interpolation error of the last -j dimensions. The
+best SOM is saved to the file and all results are written to the output as CSV
+or as JSON if the output ends in .json. The split is stratified by the class
+labels if a label column is set.`
 
 	a, err := docopt.Parse(usage, nil, true, "gosom 0.1", false)
 	if err != nil {
@@ -84,6 +218,15 @@ Options:
 		interpolate:          getBool(a["interpolate"]),
 		plot:                 getBool(a["plot"]),
 		test:                 getBool(a["test"]),
+		impute:               getBool(a["impute"]),
+		cluster:              getBool(a["cluster"]),
+		calibrate:            getBool(a["calibrate"]),
+		detect:               getBool(a["detect"]),
+		umatrix:              getBool(a["umatrix"]),
+		convert:              getBool(a["convert"]),
+		esom:                 getBool(a["esom"]),
+		crossval:             getBool(a["crossval"]),
+		tune:                 getBool(a["tune"]),
 		functions:            getBool(a["-f"]),
 		file:                 getString(a["<file>"]),
 		directory:            getString(a["<directory>"]),
@@ -106,6 +249,53 @@ Options:
 		prefix:               getString(a["-p"]),
 		testDimensions:       getInt(a["-j"]),
 		quiet:                getBool(a["-q"]),
+		output:               getString(a["<output>"]),
+		imputationMethod:     getString(a["-e"]),
+		confidence:           getString(a["-o"]),
+		algorithm:            getString(a["-a"]),
+		clusters:             getInt(a["-u"]),
+		linkage:              getString(a["-x"]),
+		percentile:           getFloat(a["--percentile"]),
+		contamination:        getFloat(a["--contamination"]),
+		normalized:           getBool(a["--normalized"]),
+		labels:               getOptionalInt(a["--labels"]),
+		colorMap:             getString(a["--colormap"]),
+		legend:               getBool(a["--legend"]),
+		names:                getList(a["--names"]),
+		topology:             getString(a["--topology"]),
+		edges:                getBool(a["--edges"]),
+		pMatrix:              getBool(a["--pmatrix"]),
+		uStarMatrix:          getBool(a["--ustar"]),
+		format:               getString(a["--format"]),
+		labelFile:            getString(a["--label-file"]),
+		allLabels:            getBool(a["--all-labels"]),
+		projection:           getString(a["--projection"]),
+		animation:            getString(a["--animate"]),
+		interval:             getInt(a["--interval"]),
+		planes:               getIntList(a["--planes"]),
+		seed:                 int64(getOptionalInt(a["--seed"])),
+		checkpoint:           getInt(a["--checkpoint"]),
+		resume:               getBool(a["--resume"]),
+		idxLabels:            getString(a["--idx-labels"]),
+		precision:            getInt(a["--precision"]),
+		delimiter:            getString(a["--delimiter"]),
+		comment:              getString(a["--comment"]),
+		header:               getBool(a["--header"]),
+		missing:              getList(a["--missing"]),
+		strict:               getBool(a["--strict"]),
+		columns:              getIntList(a["--columns"]),
+		folds:                getInt(a["--folds"]),
+		sizes:                getList(a["--sizes"]),
+		search:               getString(a["--search"]),
+		candidates:           getInt(a["--candidates"]),
+		metric:               getString(a["--metric"]),
+		ratio:                getFloat(a["--ratio"]),
+		workers:              getInt(a["--workers"]),
+		neighborhoods:        getList(a["-n"]),
+		coolings:             getList(a["-c"]),
+		stepList:             getIntList(a["-t"]),
+		learningRates:        getFloatList(a["-l"]),
+		radii:                getFloatList(a["-r"]),
 	}
 }
 
@@ -140,6 +330,39 @@ func getInt(v interface{}) int {
 	return i
 }
 
+func getOptionalInt(v interface{}) int {
+	if getString(v) == "" {
+		return -1
+	}
+
+	return getInt(v)
+}
+
+func getList(v interface{}) []string {
+	s := getString(v)
+
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, ",")
+}
+
+func getIntList(v interface{}) []int {
+	var list []int
+
+	for _, s := range getList(v) {
+		i, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			panic(err)
+		}
+
+		list = append(list, i)
+	}
+
+	return list
+}
+
 func getFloat(v interface{}) float64 {
 	s := getString(v)
 
@@ -150,3 +373,18 @@ func getFloat(v interface{}) float64 {
 
 	return f
 }
+
+func getFloatList(v interface{}) []float64 {
+	var list []float64
+
+	for _, s := range getList(v) {
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			panic(err)
+		}
+
+		list = append(list, f)
+	}
+
+	return list
+}
@@ -1,10 +1,21 @@
 package main
 
 import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"image"
+	"io"
 	"math"
+	"math/rand"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/256dpi/gosom"
 	"github.com/cheggaaa/pb"
@@ -27,19 +38,84 @@ func main() {
 		doInterpolation(c)
 	} else if c.test {
 		doTest(c)
+	} else if c.impute {
+		doImpute(c)
+	} else if c.cluster {
+		doCluster(c)
+	} else if c.calibrate {
+		doCalibrate(c)
+	} else if c.detect {
+		doDetect(c)
+	} else if c.umatrix {
+		doUMatrix(c)
+	} else if c.convert {
+		doConvert(c)
+	} else if c.esom {
+		doESOM(c)
+	} else if c.crossval {
+		doCrossval(c)
+	} else if c.tune {
+		doTune(c)
 	} else if c.functions {
 		doFunctions()
 	}
 }
 
 func doPrepare(config *config) {
+	randomSeed(config)
+
+	som := newSOM(config)
+
+	if isSparse(config.data) {
+		// sparse data is always initialized with data points
+		som.InitializeWithSparseDataPoints(loadSparseData(config.data))
+
+		model := gosom.NewModel(som)
+		model.Names = config.names
+
+		storeModel(config.file, model)
+		fmt.Printf("Prepared new SOM and saved to '%s'.\n", config.file)
+		return
+	}
+
+	data, header := loadTrainingData(config)
+	initializeSOM(config, som, data)
+
+	model := gosom.NewModel(som)
+	model.Names = config.names
+
+	if model.Names == nil {
+		model.Names = header
+	}
+
+	storeModel(config.file, model)
+	fmt.Printf("Prepared new SOM and saved to '%s'.\n", config.file)
+}
+
+// newSOM returns a new SOM with the configured functions, topology and
+// precision.
+func newSOM(config *config) *gosom.SOM {
 	som := gosom.NewSOM(config.width, config.height)
-	data := loadData(config.data)
 
 	som.DistanceFunction = config.distanceFunction
 	som.NeighborhoodFunction = config.neighborhoodFunction
 	som.CoolingFunction = config.coolingFunction
+	som.Topology = config.topology
+
+	switch config.precision {
+	case 64:
+	case 32:
+		som.Precision = 32
+	default:
+		fmt.Println("Unknown precision!")
+		os.Exit(1)
+	}
+
+	return som
+}
 
+// initializeSOM initializes the SOM with the configured method.
+func initializeSOM(config *config, som *gosom.SOM, data *gosom.Matrix) {
 	switch config.initialization {
 	case "random":
 		som.InitializeWithRandomValues(data)
@@ -51,15 +127,10 @@ func doPrepare(config *config) {
 
 		som.InitializeWithDataPoints(data)
 	}
-
-	storeSOM(config.file, som)
-	fmt.Printf("Prepared new SOM and saved to '%s'.\n", config.file)
 }
 
-func doTrain(config *config) {
-	som := loadSOM(config.file)
-	data := loadData(config.data)
-
+// newTraining returns a new training with the configured settings.
+func newTraining(config *config, som *gosom.SOM, seed int64) *gosom.Training {
 	training := gosom.NewTraining(
 		som,
 		config.trainingSteps,
@@ -73,44 +144,296 @@ func doTrain(config *config) {
 		training.InitialRadius = math.Max(float64(som.Width), float64(som.Height)) / 2.0
 	}
 
-	bar := pb.StartNew(config.trainingSteps)
+	training.Source = gosom.NewSource(seed)
+
+	return training
+}
+
+func doTrain(config *config) {
+	if config.animation != "" {
+		checkColorMap(config)
+	}
+
+	// the binary format does not hold the training state
+	if (config.checkpoint > 0 || config.resume) && strings.HasSuffix(config.file, ".bin") {
+		fmt.Println("Checkpoints are not supported with binary files!")
+		os.Exit(1)
+	}
+
+	model := loadModel(config.file)
+
+	if isSparse(config.data) {
+		trainSparse(config, model)
+		return
+	}
+
+	som := model.SOM
+	data, _ := loadTrainingData(config)
+
+	var training *gosom.Training
+	var seed int64
+	var start int
+
+	if config.resume {
+		state := model.Checkpoint
+
+		if state == nil {
+			fmt.Println("No checkpoint to resume from!")
+			os.Exit(1)
+		}
+
+		if state.Fingerprint != gosom.Fingerprint(data) {
+			fmt.Println("Data does not match the checkpoint!")
+			os.Exit(1)
+		}
+
+		if state.Topology != som.Topology || state.Precision != som.Precision {
+			fmt.Println("Topology or precision does not match the checkpoint!")
+			os.Exit(1)
+		}
+
+		training = state.Training(som)
+		seed = state.Seed
+		start = state.Step
+	} else {
+		seed = randomSeed(config)
+		training = newTraining(config, som, seed)
+	}
+
+	if config.animation != "" {
+		// out of range planes would otherwise result in empty frames
+		for _, plane := range config.planes {
+			if plane < 0 || plane >= som.Dimensions() {
+				fmt.Printf("Plane %d is out of range!\n", plane)
+				os.Exit(1)
+			}
+		}
+
+		training.Animation = gosom.NewAnimation(som, config.interval, config.size)
+		training.Animation.Planes = config.planes
+		training.Animation.Options.ColorMap = config.colorMap
+		training.Animation.Options.Invert = config.colorMap == "gray" && len(config.planes) == 0
+	}
+
+	bar := pb.StartNew(training.Steps)
+	bar.Set(start)
 
-	for step := 0; step < config.trainingSteps; step++ {
+	for step := start; step < training.Steps; step++ {
 		som.Step(data, step, training)
 		bar.Increment()
+
+		if config.checkpoint > 0 && (step+1)%config.checkpoint == 0 && step+1 < training.Steps {
+			model.Checkpoint = training.State(step+1, seed, data)
+			storeModel(config.file, model)
+		}
+	}
+
+	bar.Finish()
+
+	model.Checkpoint = nil
+	model.AddRun(training, data, seed)
+
+	storeModel(config.file, model)
+	fmt.Printf("Trained SOM and saved to '%s'.\n", config.file)
+
+	if training.Animation != nil {
+		output := createOutput(config.animation)
+		defer output.Close()
+
+		err := training.Animation.Encode(output)
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("Saved animation with %d frames to '%s'.\n", training.Animation.Frames(), config.animation)
+	}
+}
+
+// trainSparse trains the SOM with sparse data.
+func trainSparse(config *config, model *gosom.Model) {
+	if config.checkpoint > 0 || config.resume {
+		fmt.Println("Checkpoints are not supported with sparse data!")
+		os.Exit(1)
+	}
+
+	som := model.SOM
+	data := loadSparseData(config.data)
+
+	if data.Columns > som.Dimensions() {
+		fmt.Printf("Data has %d dimensions but the SOM only %d!\n", data.Columns, som.Dimensions())
+		os.Exit(1)
+	}
+
+	seed := randomSeed(config)
+	training := newTraining(config, som, seed)
+
+	sparse := gosom.NewSparseSOM(som)
+	bar := pb.StartNew(training.Steps)
+
+	for step := 0; step < training.Steps; step++ {
+		sparse.Step(data, step, training)
+		bar.Increment()
 	}
 
 	bar.Finish()
+	sparse.Flush()
+
+	model.AddSparseRun(training, data, seed)
 
-	storeSOM(config.file, som)
+	storeModel(config.file, model)
 	fmt.Printf("Trained SOM and saved to '%s'.\n", config.file)
 }
 
 func doPlot(config *config) {
-	som := loadSOM(config.file)
+	checkColorMap(config)
 
-	dimensions := gosom.DrawDimensions(som, config.size)
+	model := loadModel(config.file)
+	som := model.SOM
 
-	for i, dimension := range dimensions {
-		file := fmt.Sprintf("%s/%s-dimension-%d.png", config.directory, config.prefix, i)
+	if config.projection != "" && len(som.Nodes) > gosom.MaxProjectionNodes {
+		fmt.Printf("Projections are limited to %d nodes!\n", gosom.MaxProjectionNodes)
+		os.Exit(1)
+	}
 
-		err := draw2dimg.SaveToPngFile(file, dimension)
-		if err != nil {
-			panic(err)
+	svg := config.format == "svg"
+
+	names := config.names
+	if names == nil {
+		names = model.Names
+	}
+
+	var data *gosom.Matrix
+	var labels [][]string
+
+	if config.data != "" {
+		data, _ = loadDataWithOptions(config.data, csvOptions(config))
+
+		if config.labelFile != "" {
+			labels = som.NodeLabels(data, loadLabels(config.labelFile))
+		} else if config.labels >= 0 {
+			labels = som.NodeLabelsFromColumn(data, config.labels)
 		}
+	}
 
-		fmt.Printf("Plotted dimension to '%s'.\n", file)
+	options := gosom.DrawOptions{
+		ColorMap:  config.colorMap,
+		Legend:    config.legend,
+		Titles:    names,
+		Labels:    labels,
+		AllLabels: config.allLabels,
 	}
 
-	uMatrix := gosom.DrawUMatrix(som, config.size)
-	file := fmt.Sprintf("%s/%s-umatrix.png", config.directory, config.prefix)
+	if svg {
+		for i, dimension := range gosom.DrawDimensionsSVG(som, config.size, options) {
+			file := saveSVG(config, fmt.Sprintf("dimension-%d", i), dimension)
+			fmt.Printf("Plotted dimension to '%s'.\n", file)
+		}
+	} else {
+		for i, dimension := range gosom.DrawDimensionsWithOptions(som, config.size, options) {
+			file := saveImage(config, fmt.Sprintf("dimension-%d", i), dimension)
+			fmt.Printf("Plotted dimension to '%s'.\n", file)
+		}
+	}
 
-	err := draw2dimg.SaveToPngFile(file, uMatrix)
-	if err != nil {
-		panic(err)
+	options = gosom.DrawOptions{
+		ColorMap:  config.colorMap,
+		Legend:    config.legend,
+		Invert:    config.colorMap == "gray",
+		Labels:    labels,
+		AllLabels: config.allLabels,
+	}
+
+	var file string
+	if svg {
+		file = saveSVG(config, "umatrix", gosom.DrawUMatrixSVG(som, config.size, options))
+	} else {
+		file = saveImage(config, "umatrix", gosom.DrawUMatrixWithOptions(som, config.size, options))
 	}
 
 	fmt.Printf("Plotted U-Matrix to '%s'.\n", file)
+
+	if config.projection != "" {
+		var projection *gosom.Matrix
+
+		switch config.projection {
+		case "sammon":
+			projection = som.Sammon(100)
+		case "mds":
+			projection = som.MDS()
+		}
+
+		if projection == nil {
+			fmt.Println("Unknown projection!")
+			os.Exit(1)
+		}
+
+		size := config.size * max(som.Width, som.Height)
+
+		if svg {
+			file = saveSVG(config, "projection", gosom.DrawProjectionSVG(som, projection, size))
+		} else {
+			file = saveImage(config, "projection", gosom.DrawProjection(som, projection, size))
+		}
+
+		fmt.Printf("Plotted projection to '%s'.\n", file)
+	}
+
+	if data == nil {
+		return
+	}
+
+	if svg {
+		file = saveSVG(config, "hits", gosom.DrawHitMapSVG(som, data, config.size))
+	} else {
+		file = saveImage(config, "hits", gosom.DrawHitMap(som, data, config.size))
+	}
+
+	fmt.Printf("Plotted hit map to '%s'.\n", file)
+
+	if config.pMatrix {
+		options := gosom.DrawOptions{
+			ColorMap: config.colorMap,
+			Legend:   config.legend,
+		}
+
+		if svg {
+			file = saveSVG(config, "pmatrix", gosom.DrawPMatrixSVG(som, data, config.size, options))
+		} else {
+			file = saveImage(config, "pmatrix", gosom.DrawPMatrixWithOptions(som, data, config.size, options))
+		}
+
+		fmt.Printf("Plotted P-Matrix to '%s'.\n", file)
+	}
+
+	if config.uStarMatrix {
+		if svg {
+			file = saveSVG(config, "ustarmatrix", gosom.DrawUStarMatrixSVG(som, data, config.size, options))
+		} else {
+			file = saveImage(config, "ustarmatrix", gosom.DrawUStarMatrixWithOptions(som, data, config.size, options))
+		}
+
+		fmt.Printf("Plotted U*-Matrix to '%s'.\n", file)
+	}
+
+	if config.labels < 0 {
+		return
+	}
+
+	if svg {
+		classes, hitMaps := gosom.DrawClassHitMapsSVG(som, data, config.labels, config.size)
+
+		for i, hitMap := range hitMaps {
+			file := saveSVG(config, fmt.Sprintf("hits-%g", classes[i]), hitMap)
+			fmt.Printf("Plotted hit map for label %g to '%s'.\n", classes[i], file)
+		}
+	} else {
+		classes, hitMaps := gosom.DrawClassHitMaps(som, data, config.labels, config.size)
+
+		for i, hitMap := range hitMaps {
+			file := saveImage(config, fmt.Sprintf("hits-%g", classes[i]), hitMap)
+			fmt.Printf("Plotted hit map for label %g to '%s'.\n", classes[i], file)
+		}
+	}
 }
 
 func doClassification(config *config) {
@@ -134,7 +457,7 @@ func doInterpolation(config *config) {
 
 func doTest(config *config) {
 	som := loadSOM(config.file)
-	data := loadData(config.data)
+	data, _ := loadTrainingData(config)
 	test := data.SubMatrix(0, data.Columns-config.testDimensions)
 
 	fmt.Println("Classification tests:")
@@ -153,7 +476,93 @@ func doTest(config *config) {
 	})
 }
 
+func doCrossval(config *config) {
+	// the classification and interpolation errors are undefined without tested
+	// dimensions
+	if config.testDimensions < 1 {
+		fmt.Println("Cross-validation requires at least one tested dimension!")
+		os.Exit(1)
+	}
+
+	seed := randomSeed(config)
+	data, _ := loadTrainingData(config)
+	random := rand.New(rand.NewSource(seed))
+
+	var folds []gosom.Fold
+	var err error
+	if config.labels >= 0 {
+		folds, err = data.StratifiedKFold(config.labels, config.folds, random)
+	} else {
+		folds, err = data.KFold(config.folds, random)
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	metrics := []string{
+		"Classification error",
+		fmt.Sprintf("Interpolation error (K=%d)", config.nearestNeighbors),
+		fmt.Sprintf("Weighted interpolation error (K=%d)", config.nearestNeighbors),
+		"Quantization error",
+		"Topographic error",
+	}
+
+	results := make([][]float64, len(metrics))
+
+	fmt.Printf("Cross-validating with %d folds:\n", len(folds))
+
+	for i, fold := range folds {
+		som := newSOM(config)
+		initializeSOM(config, som, fold.Train)
+		som.Train(fold.Train, newTraining(config, som, seed+int64(i)))
+
+		test := fold.Test.SubMatrix(0, fold.Test.Columns-config.testDimensions)
+
+		values := []float64{
+			avg(testErrors(data, fold.Test, test, som.Classify, nil)),
+			avg(testErrors(data, fold.Test, test, func(input []float64) []float64 {
+				return som.Interpolate(input, config.nearestNeighbors)
+			}, nil)),
+			avg(testErrors(data, fold.Test, test, func(input []float64) []float64 {
+				return som.WeightedInterpolate(input, config.nearestNeighbors)
+			}, nil)),
+			som.QuantizationError(fold.Test),
+			som.TopographicError(fold.Test),
+		}
+
+		fmt.Printf("  Fold %d: Classification %.2f%%, Interpolation %.2f%%, Weighted %.2f%%, QE %.4f, TE %.4f\n", i+1, values[0], values[1], values[2], values[3], values[4])
+
+		for j, value := range values {
+			results[j] = append(results[j], value)
+		}
+	}
+
+	fmt.Println()
+
+	for i, metric := range metrics {
+		if i < 3 {
+			fmt.Printf("%s: %.2f%% ± %.2f%%\n", metric, avg(results[i]), stddev(results[i]))
+		} else {
+			fmt.Printf("%s: %.4f ± %.4f\n", metric, avg(results[i]), stddev(results[i]))
+		}
+	}
+}
+
 func testHelper(config *config, data *gosom.Matrix, test *gosom.Matrix, tester func([]float64) []float64) {
+	allErrors := testErrors(data, data, test, tester, func(i int, output []float64, err float64) {
+		if !config.quiet {
+			fmt.Printf("  %.3f: %.3f (Error: %.2f%%)\n", data.Data[i], output, err)
+		}
+	})
+
+	fmt.Printf("  Min: %.2f%%, Max: %.2f%%, Avg: %.2f%%\n", floats.Min(allErrors), floats.Max(allErrors), avg(allErrors))
+}
+
+// testErrors returns the errors of the tested dimensions for every row in
+// percent of the value ranges in bounds. The report function is called for
+// every row if set.
+func testErrors(bounds, data, test *gosom.Matrix, tester func([]float64) []float64, report func(int, []float64, float64)) []float64 {
 	allErrors := make([]float64, data.Rows)
 
 	for i := 0; i < data.Rows; i++ {
@@ -162,7 +571,7 @@ func testHelper(config *config, data *gosom.Matrix, test *gosom.Matrix, tester f
 		var localErrors []float64
 
 		for j := test.Columns; j < data.Columns; j++ {
-			divider := data.Maximums[j] - data.Minimums[j]
+			divider := bounds.Maximums[j] - bounds.Minimums[j]
 			if divider == 0.0 {
 				divider = 1.0
 			}
@@ -173,79 +582,824 @@ func testHelper(config *config, data *gosom.Matrix, test *gosom.Matrix, tester f
 
 		allErrors[i] = avg(localErrors)
 
-		if !config.quiet {
-			fmt.Printf("  %.3f: %.3f (Error: %.2f%%)\n", data.Data[i], output, allErrors[i])
+		if report != nil {
+			report(i, output, allErrors[i])
 		}
 	}
 
-	fmt.Printf("  Min: %.2f%%, Max: %.2f%%, Avg: %.2f%%\n", floats.Min(allErrors), floats.Max(allErrors), avg(allErrors))
+	return allErrors
 }
 
-func doFunctions() {
-	fmt.Println("Plotting cooling functions to './cooling.png' ...")
-	plotCoolingFunctions("cooling.png")
+func doImpute(config *config) {
+	switch config.imputationMethod {
+	case "bmu", "knn", "weighted":
+	default:
+		fmt.Println("Unknown method!")
+		os.Exit(1)
+	}
 
-	fmt.Println("Plotting neighborhood functions to './neighborhood.png' ...")
-	plotNeighborhoodFunctions("neighborhood.png")
-}
+	som := loadSOM(config.file)
+	imputer := gosom.NewImputer(som, config.imputationMethod, config.nearestNeighbors)
 
-func loadData(file string) *gosom.Matrix {
-	handle, err := os.Open(file)
-	if err != nil {
-		panic(err)
+	input := openInput(config.data)
+	defer input.Close()
+
+	output := createOutput(config.output)
+	defer output.Close()
+
+	reader := newCSVReader(config, input)
+	writer := csv.NewWriter(output)
+
+	var confidenceWriter *csv.Writer
+	if config.confidence != "" {
+		confidence := createOutput(config.confidence)
+		defer confidence.Close()
+
+		confidenceWriter = csv.NewWriter(confidence)
 	}
 
-	defer handle.Close()
+	// keep the names of the selected columns
+	if names := reader.Names(); names != nil {
+		for _, w := range []*csv.Writer{writer, confidenceWriter} {
+			if w != nil {
+				err := w.Write(names)
+				if err != nil {
+					panic(err)
+				}
+			}
+		}
+	}
 
-	ds, err := gosom.LoadMatrixFromCSV(handle)
-	if err != nil {
+	for {
+		row, err := readCSVRow(config, reader)
+		if err == io.EOF {
+			break
+		}
+
+		values, confidence := imputer.Row(row)
+
+		err = writer.Write(formatRow(values))
+		if err != nil {
+			panic(err)
+		}
+
+		if confidenceWriter != nil {
+			err = confidenceWriter.Write(formatRow(confidence))
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
 		panic(err)
 	}
 
-	return ds
+	if confidenceWriter != nil {
+		confidenceWriter.Flush()
+		if err := confidenceWriter.Error(); err != nil {
+			panic(err)
+		}
+	}
 }
 
-func loadSOM(file string) *gosom.SOM {
-	handle, err := os.Open(file)
-	if err != nil {
-		panic(err)
-	}
+func doCluster(config *config) {
+	som := loadSOM(config.file)
 
-	defer handle.Close()
+	var clustering *gosom.Clustering
 
-	som, err := gosom.LoadSOMFromJSON(handle)
-	if err != nil {
-		panic(err)
+	switch config.algorithm {
+	case "kmeans":
+		clustering = gosom.KMeansClustering(som, config.clusters)
+	case "agglomerative":
+		clustering = gosom.AgglomerativeClustering(som, config.clusters, config.linkage)
+	case "watershed":
+		clustering = gosom.WatershedClustering(som)
 	}
 
-	return som
-}
+	if clustering == nil {
+		fmt.Println("Unknown clustering algorithm or linkage!")
+		os.Exit(1)
+	}
 
-func storeSOM(file string, som *gosom.SOM) {
-	handle, err := os.Create(file)
-	if err != nil {
-		panic(err)
+	var file string
+	if config.format == "svg" {
+		file = saveSVG(config, "clusters", gosom.DrawClustersSVG(clustering, config.size))
+	} else {
+		file = saveImage(config, "clusters", gosom.DrawClusters(clustering, config.size))
 	}
 
-	defer handle.Close()
+	fmt.Printf("Plotted %d clusters to '%s'.\n", clustering.Count(), file)
 
-	err = som.SaveAsJSON(handle)
-	if err != nil {
+	file = fmt.Sprintf("%s/%s-clusters.csv", config.directory, config.prefix)
+
+	output := createOutput(file)
+	defer output.Close()
+
+	writer := csv.NewWriter(output)
+
+	for i, node := range som.Nodes {
+		err := writer.Write([]string{
+			strconv.Itoa(node.X()),
+			strconv.Itoa(node.Y()),
+			strconv.Itoa(clustering.Clusters[i]),
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
 		panic(err)
 	}
+
+	fmt.Printf("Saved node clusters to '%s'.\n", file)
 }
 
-func readInput(input string) []float64 {
-	var values []float64
+func doCalibrate(config *config) {
+	model := loadModel(config.file)
+	som := model.SOM
+	data, _ := loadDataWithOptions(config.data, csvOptions(config))
+
+	detector := gosom.NewDetector(som, config.normalized)
+
+	var err error
+	if config.contamination > 0 {
+		err = detector.CalibrateContamination(data, config.contamination)
+	} else {
+		err = detector.Calibrate(data, config.percentile)
+	}
 
-	err := json.Unmarshal([]byte(input), &values)
 	if err != nil {
 		panic(err)
 	}
 
-	return values
+	storeModel(config.file, model)
+	fmt.Printf("Calibrated threshold %g and saved to '%s'.\n", detector.Threshold, config.file)
 }
 
-func avg(v []float64) float64 {
-	return floats.Sum(v) / float64(len(v))
+func doDetect(config *config) {
+	som := loadSOM(config.file)
+
+	if som.Detector == nil {
+		fmt.Println("SOM has not been calibrated for anomaly detection!")
+		os.Exit(1)
+	}
+
+	input := openInput(config.data)
+	defer input.Close()
+
+	output := createOutput(config.output)
+	defer output.Close()
+
+	reader := newCSVReader(config, input)
+	writer := csv.NewWriter(output)
+
+	// keep all columns and append the results
+	if header := reader.Header(); header != nil {
+		err := writer.Write(append(header, "Score", "Anomaly"))
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	rows := 0
+	anomalies := 0
+
+	for {
+		row, err := readCSVRow(config, reader)
+		if err == io.EOF {
+			break
+		}
+
+		record := reader.Record()
+
+		score := som.Detector.Score(row)
+		flag := "0"
+
+		if score > som.Detector.Threshold {
+			flag = "1"
+			anomalies++
+		}
+
+		rows++
+
+		err = writer.Write(append(record, strconv.FormatFloat(score, 'g', -1, 64), flag))
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		panic(err)
+	}
+
+	if config.output != "-" {
+		fmt.Printf("Flagged %d of %d rows and saved to '%s'.\n", anomalies, rows, config.output)
+	}
+}
+
+func doUMatrix(config *config) {
+	som := loadSOM(config.file)
+
+	output := createOutput(config.output)
+	defer output.Close()
+
+	writer := csv.NewWriter(output)
+
+	if config.edges {
+		for _, edge := range som.UMatrixEdges() {
+			from := som.Nodes[edge.From]
+			to := som.Nodes[edge.To]
+
+			err := writer.Write([]string{
+				strconv.Itoa(from.X()),
+				strconv.Itoa(from.Y()),
+				strconv.Itoa(to.X()),
+				strconv.Itoa(to.Y()),
+				strconv.FormatFloat(edge.Distance, 'g', -1, 64),
+			})
+			if err != nil {
+				panic(err)
+			}
+		}
+	} else {
+		for i, value := range som.UMatrix() {
+			err := writer.Write([]string{
+				strconv.Itoa(som.Nodes[i].X()),
+				strconv.Itoa(som.Nodes[i].Y()),
+				strconv.FormatFloat(value, 'g', -1, 64),
+			})
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		panic(err)
+	}
+
+	if config.output != "-" {
+		fmt.Printf("Exported U-Matrix to '%s'.\n", config.output)
+	}
+}
+
+func doConvert(config *config) {
+	in := filepath.Ext(config.input)
+	out := filepath.Ext(config.output)
+
+	isSOM := func(ext string) bool {
+		return ext != ".dat" && ext != ".csv" && ext != ".lrn"
+	}
+
+	switch {
+	case (in == ".cod" || in == ".wts") && isSOM(out) && out != ".cod" && out != ".wts":
+		handle, err := os.Open(config.input)
+		if err != nil {
+			panic(err)
+		}
+
+		defer handle.Close()
+
+		var som *gosom.SOM
+		var names []string
+
+		if in == ".cod" {
+			som, names, err = gosom.LoadSOMFromCOD(handle)
+		} else {
+			som, err = gosom.LoadSOMFromWTS(handle)
+		}
+
+		if err != nil {
+			panic(err)
+		}
+
+		model := gosom.NewModel(som)
+		model.Names = names
+
+		storeModel(config.output, model)
+		fmt.Printf("Converted SOM to '%s'.\n", config.output)
+	case (out == ".cod" || out == ".wts") && isSOM(in):
+		model := loadModel(config.input)
+
+		output := createOutput(config.output)
+		defer output.Close()
+
+		var err error
+		if out == ".cod" {
+			err = model.SOM.SaveAsCOD(output, model.Names)
+		} else {
+			err = model.SOM.SaveAsWTS(output)
+		}
+
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("Converted SOM to '%s'.\n", config.output)
+	case (in == ".dat" || in == ".lrn") && out == ".csv":
+		handle, err := os.Open(config.input)
+		if err != nil {
+			panic(err)
+		}
+
+		defer handle.Close()
+
+		var data *gosom.Matrix
+		if in == ".dat" {
+			data, err = gosom.LoadMatrixFromDAT(handle)
+		} else {
+			data, _, err = gosom.LoadMatrixFromLRN(handle)
+		}
+
+		if err != nil {
+			panic(err)
+		}
+
+		output := createOutput(config.output)
+		defer output.Close()
+
+		writer := csv.NewWriter(output)
+
+		for _, row := range data.Data {
+			err = writer.Write(formatRow(row))
+			if err != nil {
+				panic(err)
+			}
+		}
+
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("Converted data to '%s'.\n", config.output)
+	case in == ".csv" && (out == ".dat" || out == ".lrn"):
+		data, names := loadDataWithOptions(config.input, csvOptions(config))
+
+		output := createOutput(config.output)
+		defer output.Close()
+
+		var err error
+		if out == ".dat" {
+			err = data.SaveAsDAT(output)
+		} else {
+			err = data.SaveAsLRN(output, names)
+		}
+
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("Converted data to '%s'.\n", config.output)
+	default:
+		fmt.Println("Unsupported conversion!")
+		os.Exit(1)
+	}
+}
+
+func doESOM(config *config) {
+	model := loadModel(config.file)
+	som := model.SOM
+	data, _ := loadDataWithOptions(config.data, csvOptions(config))
+
+	create := func(ext string) (io.WriteCloser, string) {
+		file := fmt.Sprintf("%s/%s.%s", config.directory, config.prefix, ext)
+		return createOutput(file), file
+	}
+
+	write := func(ext string, fn func(io.Writer) error) {
+		output, file := create(ext)
+		defer output.Close()
+
+		err := fn(output)
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("Exported %s to '%s'.\n", ext, file)
+	}
+
+	var matches []gosom.BestMatch
+	var classes []int
+
+	if config.labels < 0 {
+		matches = som.BestMatches(data)
+	} else {
+		// search best matches with masked labels
+		matches = make([]gosom.BestMatch, data.Rows)
+		classes = make([]int, data.Rows)
+		input := make([]float64, data.Columns)
+
+		for i, row := range data.Data {
+			copy(input, row)
+			input[config.labels] = math.NaN()
+
+			node := som.Closest(input)
+			matches[i] = gosom.BestMatch{Key: i + 1, X: node.X(), Y: node.Y()}
+			classes[i] = int(row[config.labels])
+		}
+	}
+
+	write("lrn", func(w io.Writer) error {
+		// the labels are only exported as classes
+		if config.labels >= 0 {
+			return data.SaveAsLRNWithIgnored(w, model.Names, []int{config.labels})
+		}
+
+		return data.SaveAsLRN(w, model.Names)
+	})
+
+	write("wts", som.SaveAsWTS)
+
+	write("bm", func(w io.Writer) error {
+		return som.SaveBestMatchesAsBM(w, matches)
+	})
+
+	write("umx", som.SaveUMatrixAsUMX)
+
+	if classes != nil {
+		write("cls", func(w io.Writer) error {
+			return gosom.SaveClassesAsCLS(w, classes)
+		})
+	}
+}
+
+func doFunctions() {
+	fmt.Println("Plotting cooling functions to './cooling.png' ...")
+	plotCoolingFunctions("cooling.png")
+
+	fmt.Println("Plotting neighborhood functions to './neighborhood.png' ...")
+	plotNeighborhoodFunctions("neighborhood.png")
+}
+
+// randomSeed seeds the global random number generator with the configured or
+// a random seed and returns it.
+func randomSeed(config *config) int64 {
+	seed := config.seed
+	if seed < 0 {
+		seed = time.Now().UnixNano() & math.MaxInt64
+	}
+
+	rand.Seed(seed)
+
+	return seed
+}
+
+// loadDataWithOptions loads idx or csv data and returns the column names of a
+// csv header.
+func loadDataWithOptions(file string, options gosom.CSVOptions) (*gosom.Matrix, []string) {
+	handle, err := os.Open(file)
+	if err != nil {
+		panic(err)
+	}
+
+	defer handle.Close()
+
+	reader := bufio.NewReader(handle)
+
+	// decompress gzip streams before detecting the format
+	magic, _ := reader.Peek(2)
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		decompressor, err := gzip.NewReader(reader)
+		if err != nil {
+			panic(err)
+		}
+
+		defer decompressor.Close()
+
+		reader = bufio.NewReader(decompressor)
+	}
+
+	// detect idx files by their magic bytes
+	header, _ := reader.Peek(4)
+
+	var ds *gosom.Matrix
+	var names []string
+	if gosom.IsIDX(header) {
+		ds, err = gosom.LoadMatrixFromIDX(reader)
+	} else {
+		ds, names, err = gosom.LoadMatrixFromCSVWithOptions(reader, options)
+	}
+
+	var csvError *gosom.CSVError
+	if errors.As(err, &csvError) {
+		fmt.Printf("Invalid data in '%s': %s\n", file, csvError)
+		os.Exit(1)
+	} else if err != nil {
+		panic(err)
+	}
+
+	return ds, names
+}
+
+// loadTrainingData loads the data using the csv options and appends the
+// labels of an idx label file as the last column if configured. The column
+// names of a csv header are returned as well.
+func loadTrainingData(config *config) (*gosom.Matrix, []string) {
+	if config.idxLabels == "" {
+		return loadDataWithOptions(config.data, csvOptions(config))
+	}
+
+	images, err := os.Open(config.data)
+	if err != nil {
+		panic(err)
+	}
+
+	defer images.Close()
+
+	labels, err := os.Open(config.idxLabels)
+	if err != nil {
+		panic(err)
+	}
+
+	defer labels.Close()
+
+	ds, _, err := gosom.LoadMatrixFromIDXWithLabels(images, labels, true)
+	if err != nil {
+		panic(err)
+	}
+
+	return ds, nil
+}
+
+// csvOptions returns the configured csv options.
+func csvOptions(config *config) gosom.CSVOptions {
+	options := gosom.CSVOptions{
+		Header:  config.header,
+		Missing: config.missing,
+		Strict:  config.strict,
+		Columns: config.columns,
+	}
+
+	switch config.delimiter {
+	case "":
+	case "tab", "\\t":
+		options.Delimiter = '\t'
+	default:
+		runes := []rune(config.delimiter)
+		if len(runes) != 1 {
+			fmt.Println("Invalid delimiter!")
+			os.Exit(1)
+		}
+
+		options.Delimiter = runes[0]
+	}
+
+	if config.comment != "" {
+		options.Comment = []rune(config.comment)[0]
+	}
+
+	return options
+}
+
+// newCSVReader returns a reader that streams the csv input using the csv
+// options.
+func newCSVReader(config *config, input io.Reader) *gosom.CSVReader {
+	reader, err := gosom.NewCSVReader(input, csvOptions(config))
+	if err != nil {
+		panic(err)
+	}
+
+	return reader
+}
+
+// readCSVRow returns the next row of the reader or io.EOF. It exits on
+// invalid data like loadDataWithOptions.
+func readCSVRow(config *config, reader *gosom.CSVReader) ([]float64, error) {
+	row, err := reader.Read()
+
+	var csvError *gosom.CSVError
+	if err == io.EOF {
+		return nil, err
+	} else if errors.As(err, &csvError) {
+		fmt.Printf("Invalid data in '%s': %s\n", config.data, csvError)
+		os.Exit(1)
+	} else if err != nil {
+		panic(err)
+	}
+
+	return row, nil
+}
+
+// isSparse reports whether the file holds sparse data in the LIBSVM format.
+func isSparse(file string) bool {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".svm", ".libsvm", ".svmlight":
+		return true
+	}
+
+	return false
+}
+
+func loadSparseData(file string) *gosom.SparseMatrix {
+	handle, err := os.Open(file)
+	if err != nil {
+		panic(err)
+	}
+
+	defer handle.Close()
+
+	ds, _, err := gosom.LoadSparseMatrixFromLIBSVM(handle)
+	if err != nil {
+		panic(err)
+	}
+
+	return ds
+}
+
+func loadLabels(file string) []string {
+	handle, err := os.Open(file)
+	if err != nil {
+		panic(err)
+	}
+
+	defer handle.Close()
+
+	var labels []string
+
+	scanner := bufio.NewScanner(handle)
+	for scanner.Scan() {
+		labels = append(labels, strings.TrimSpace(scanner.Text()))
+	}
+
+	if err := scanner.Err(); err != nil {
+		panic(err)
+	}
+
+	return labels
+}
+
+func loadSOM(file string) *gosom.SOM {
+	return loadModel(file).SOM
+}
+
+func loadModel(file string) *gosom.Model {
+	handle, err := os.Open(file)
+	if err != nil {
+		panic(err)
+	}
+
+	defer handle.Close()
+
+	model, err := gosom.LoadModel(handle)
+	if err != nil {
+		panic(err)
+	}
+
+	return model
+}
+
+func storeModel(file string, model *gosom.Model) {
+	// write to a temporary file that replaces the original atomically
+	handle, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*.tmp")
+	if err != nil {
+		panic(err)
+	}
+
+	defer os.Remove(handle.Name())
+	defer handle.Close()
+
+	// the binary format only holds the SOM
+	if strings.HasSuffix(file, ".bin") {
+		precision := 64
+		if model.SOM.Precision == 32 {
+			precision = 32
+		}
+
+		err = model.SOM.SaveBinary(handle, precision)
+	} else {
+		err = model.Save(handle)
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	err = handle.Chmod(0644)
+	if err != nil {
+		panic(err)
+	}
+
+	err = handle.Sync()
+	if err != nil {
+		panic(err)
+	}
+
+	err = handle.Close()
+	if err != nil {
+		panic(err)
+	}
+
+	err = os.Rename(handle.Name(), file)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func saveImage(config *config, name string, img image.Image) string {
+	file := fmt.Sprintf("%s/%s-%s.png", config.directory, config.prefix, name)
+
+	err := draw2dimg.SaveToPngFile(file, img)
+	if err != nil {
+		panic(err)
+	}
+
+	return file
+}
+
+func saveSVG(config *config, name string, svg *gosom.SVG) string {
+	file := fmt.Sprintf("%s/%s-%s.svg", config.directory, config.prefix, name)
+
+	output := createOutput(file)
+	defer output.Close()
+
+	_, err := svg.WriteTo(output)
+	if err != nil {
+		panic(err)
+	}
+
+	return file
+}
+
+func openInput(file string) io.ReadCloser {
+	if file == "-" {
+		return os.Stdin
+	}
+
+	handle, err := os.Open(file)
+	if err != nil {
+		panic(err)
+	}
+
+	return handle
+}
+
+func createOutput(file string) io.WriteCloser {
+	if file == "-" {
+		return os.Stdout
+	}
+
+	handle, err := os.Create(file)
+	if err != nil {
+		panic(err)
+	}
+
+	return handle
+}
+
+func formatRow(values []float64) []string {
+	record := make([]string, len(values))
+
+	for i, value := range values {
+		record[i] = strconv.FormatFloat(value, 'g', -1, 64)
+	}
+
+	return record
+}
+
+func readInput(input string) []float64 {
+	var values []float64
+
+	err := json.Unmarshal([]byte(input), &values)
+	if err != nil {
+		panic(err)
+	}
+
+	return values
+}
+
+// checkColorMap exits if the configured color map is unknown.
+func checkColorMap(config *config) {
+	switch config.colorMap {
+	case "gray", "viridis", "magma", "bluered":
+	default:
+		fmt.Println("Unknown color map!")
+		os.Exit(1)
+	}
+}
+
+func avg(v []float64) float64 {
+	return floats.Sum(v) / float64(len(v))
+}
+
+// stddev returns the sample standard deviation.
+func stddev(v []float64) float64 {
+	if len(v) < 2 {
+		return 0
+	}
+
+	m := avg(v)
+	sum := 0.0
+
+	for _, value := range v {
+		sum += (value - m) * (value - m)
+	}
+
+	return math.Sqrt(sum / float64(len(v)-1))
 }
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/256dpi/gosom"
+	"github.com/256dpi/gosom/functions"
+	"github.com/cheggaaa/pb"
+)
+
+// A candidate is a combination of parameters that is evaluated by the tune
+// command.
+type candidate struct {
+	Rank                 int
+	Width                int
+	Height               int
+	NeighborhoodFunction string
+	CoolingFunction      string
+	InitialLearningRate  float64
+	InitialRadius        float64
+	Steps                int
+	QuantizationError    float64
+	TopographicError     float64
+	InterpolationError   float64
+}
+
+var candidateHeader = []string{
+	"Rank",
+	"Width",
+	"Height",
+	"NeighborhoodFunction",
+	"CoolingFunction",
+	"InitialLearningRate",
+	"InitialRadius",
+	"Steps",
+	"QuantizationError",
+	"TopographicError",
+	"InterpolationError",
+}
+
+// record returns the fields of the candidate in the order of the header.
+func (c *candidate) record() []string {
+	f := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	return []string{
+		strconv.Itoa(c.Rank),
+		strconv.Itoa(c.Width),
+		strconv.Itoa(c.Height),
+		c.NeighborhoodFunction,
+		c.CoolingFunction,
+		f(c.InitialLearningRate),
+		f(c.InitialRadius),
+		strconv.Itoa(c.Steps),
+		f(c.QuantizationError),
+		f(c.TopographicError),
+		f(c.InterpolationError),
+	}
+}
+
+// score returns the value of the metric that ranks the candidate.
+func (c *candidate) score(metric string) float64 {
+	switch metric {
+	case "te":
+		return c.TopographicError
+	case "interpolation":
+		return c.InterpolationError
+	default:
+		return c.QuantizationError
+	}
+}
+
+func doTune(config *config) {
+	switch config.metric {
+	case "qe", "te", "interpolation":
+	default:
+		fmt.Println("Unknown metric!")
+		os.Exit(1)
+	}
+
+	// the interpolation error is undefined without tested dimensions
+	if config.metric == "interpolation" && config.testDimensions < 1 {
+		fmt.Println("The interpolation metric requires at least one tested dimension!")
+		os.Exit(1)
+	}
+
+	if config.candidates < 1 {
+		fmt.Println("The number of candidates must be at least one!")
+		os.Exit(1)
+	}
+
+	seed := randomSeed(config)
+	data, header := loadTrainingData(config)
+	random := rand.New(rand.NewSource(seed))
+
+	var train, test *gosom.Matrix
+	var err error
+	if config.labels >= 0 {
+		train, test, err = data.StratifiedSplit(config.labels, config.ratio, random)
+	} else {
+		train, test, err = data.Split(config.ratio, random)
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	candidates := tuneCandidates(config)
+
+	switch config.search {
+	case "grid":
+	case "random":
+		random.Shuffle(len(candidates), func(i, j int) {
+			candidates[i], candidates[j] = candidates[j], candidates[i]
+		})
+
+		if config.candidates < len(candidates) {
+			candidates = candidates[:config.candidates]
+		}
+	default:
+		fmt.Println("Unknown search!")
+		os.Exit(1)
+	}
+
+	workers := config.workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	if workers > len(candidates) {
+		workers = len(candidates)
+	}
+
+	input := test.SubMatrix(0, test.Columns-config.testDimensions)
+
+	type job struct {
+		index    int
+		som      *gosom.SOM
+		training *gosom.Training
+	}
+
+	var best *job
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+
+	jobs := make(chan *job)
+
+	fmt.Printf("Tuning %d candidates with %d workers:\n", len(candidates), workers)
+
+	bar := pb.StartNew(len(candidates))
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := range jobs {
+				som := j.som
+				som.Train(train, j.training)
+
+				c := &candidates[j.index]
+				c.InitialRadius = j.training.InitialRadius
+				c.QuantizationError = som.QuantizationError(test)
+				c.TopographicError = som.TopographicError(test)
+				c.InterpolationError = avg(testErrors(data, test, input, func(input []float64) []float64 {
+					if config.weighted {
+						return som.WeightedInterpolate(input, config.nearestNeighbors)
+					}
+
+					return som.Interpolate(input, config.nearestNeighbors)
+				}, nil))
+
+				mutex.Lock()
+				if best == nil || c.score(config.metric) < candidates[best.index].score(config.metric) ||
+					c.score(config.metric) == candidates[best.index].score(config.metric) && j.index < best.index {
+					best = j
+				}
+				mutex.Unlock()
+
+				bar.Increment()
+			}
+		}()
+	}
+
+	// the SOMs are initialized in order as the initialization uses the global
+	// random number generator
+	for i, c := range candidates {
+		local := *config
+		local.width = c.Width
+		local.height = c.Height
+		local.neighborhoodFunction = c.NeighborhoodFunction
+		local.coolingFunction = c.CoolingFunction
+		local.initialLearningRate = c.InitialLearningRate
+		local.initialRadius = c.InitialRadius
+		local.trainingSteps = c.Steps
+
+		som := newSOM(&local)
+		initializeSOM(&local, som, train)
+
+		jobs <- &job{
+			index:    i,
+			som:      som,
+			training: newTraining(&local, som, seed+int64(i)),
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	bar.Finish()
+
+	ranking := make([]int, len(candidates))
+	for i := range ranking {
+		ranking[i] = i
+	}
+
+	sort.SliceStable(ranking, func(i, j int) bool {
+		return candidates[ranking[i]].score(config.metric) < candidates[ranking[j]].score(config.metric)
+	})
+
+	ranked := make([]candidate, len(candidates))
+	for i, index := range ranking {
+		ranked[i] = candidates[index]
+		ranked[i].Rank = i + 1
+	}
+
+	fmt.Println()
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "Rank\tSize\tNeighborhood\tCooling\tRate\tRadius\tSteps\tQE\tTE\tInterpolation")
+
+	for _, c := range ranked {
+		fmt.Fprintf(writer, "%d\t%dx%d\t%s\t%s\t%g\t%g\t%d\t%.4f\t%.4f\t%.2f%%\n", c.Rank, c.Width, c.Height, c.NeighborhoodFunction, c.CoolingFunction, c.InitialLearningRate, c.InitialRadius, c.Steps, c.QuantizationError, c.TopographicError, c.InterpolationError)
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println()
+
+	model := gosom.NewModel(best.som)
+	model.Names = config.names
+
+	if model.Names == nil {
+		model.Names = header
+	}
+
+	model.AddRun(best.training, train, seed+int64(best.index))
+
+	storeModel(config.file, model)
+	fmt.Printf("Saved best SOM to '%s'.\n", config.file)
+
+	if config.output != "-" {
+		fmt.Printf("Saving results to '%s'.\n", config.output)
+	}
+
+	storeCandidates(config.output, ranked)
+}
+
+// tuneCandidates returns the grid of all combinations of the configured
+// parameters.
+func tuneCandidates(config *config) []candidate {
+	var sizes [][2]int
+
+	for _, size := range config.sizes {
+		parts := strings.Split(strings.TrimSpace(size), "x")
+		if len(parts) != 2 {
+			fmt.Println("Invalid size!")
+			os.Exit(1)
+		}
+
+		width, err1 := strconv.Atoi(parts[0])
+		height, err2 := strconv.Atoi(parts[1])
+		if err1 != nil || err2 != nil || width <= 0 || height <= 0 {
+			fmt.Println("Invalid size!")
+			os.Exit(1)
+		}
+
+		sizes = append(sizes, [2]int{width, height})
+	}
+
+	for _, neighborhood := range config.neighborhoods {
+		if functions.LookupNeighborhood(strings.TrimSpace(neighborhood)) == nil {
+			fmt.Printf("Unknown neighborhood function '%s'!\n", strings.TrimSpace(neighborhood))
+			os.Exit(1)
+		}
+	}
+
+	for _, cooling := range config.coolings {
+		if functions.LookupCooling(strings.TrimSpace(cooling)) == nil {
+			fmt.Printf("Unknown cooling function '%s'!\n", strings.TrimSpace(cooling))
+			os.Exit(1)
+		}
+	}
+
+	var candidates []candidate
+
+	for _, size := range sizes {
+		for _, neighborhood := range config.neighborhoods {
+			for _, cooling := range config.coolings {
+				for _, rate := range config.learningRates {
+					for _, radius := range config.radii {
+						for _, steps := range config.stepList {
+							candidates = append(candidates, candidate{
+								Width:                size[0],
+								Height:               size[1],
+								NeighborhoodFunction: strings.TrimSpace(neighborhood),
+								CoolingFunction:      strings.TrimSpace(cooling),
+								InitialLearningRate:  rate,
+								InitialRadius:        radius,
+								Steps:                steps,
+							})
+						}
+					}
+				}
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		fmt.Println("No candidates!")
+		os.Exit(1)
+	}
+
+	return candidates
+}
+
+// storeCandidates writes the candidates as JSON if the file ends in .json and
+// as CSV otherwise.
+func storeCandidates(file string, candidates []candidate) {
+	output := createOutput(file)
+	defer output.Close()
+
+	if strings.ToLower(filepath.Ext(file)) == ".json" {
+		encoder := json.NewEncoder(output)
+		encoder.SetIndent("", "  ")
+
+		err := encoder.Encode(candidates)
+		if err != nil {
+			panic(err)
+		}
+
+		return
+	}
+
+	writer := csv.NewWriter(output)
+
+	err := writer.Write(candidateHeader)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, c := range candidates {
+		err = writer.Write(c.record())
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	writer.Flush()
+
+	err = writer.Error()
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,168 @@
+package gosom
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// maxIDXColumns is the largest number of values per item that is accepted
+// from an IDX header.
+const maxIDXColumns = 1 << 24
+
+// IsIDX reports whether the header starts with the magic bytes of an IDX
+// file. Gzip streams must be decompressed before they are checked.
+func IsIDX(header []byte) bool {
+	return len(header) >= 4 && header[0] == 0 && header[1] == 0 && idxSize(header[2]) > 0 && header[3] > 0
+}
+
+// LoadMatrixFromIDX reads an IDX file (e.g. from the MNIST data set) from
+// source and returns a matrix with one row per item of the first dimension.
+// The remaining dimensions are flattened into columns. Gzip compressed files
+// are decompressed transparently. Rows are allocated as they are read so that
+// a corrupt header cannot exhaust the memory.
+func LoadMatrixFromIDX(source io.Reader) (*Matrix, error) {
+	r, err := idxReader(source)
+	if err != nil {
+		return nil, err
+	}
+
+	var magic [4]byte
+	_, err = io.ReadFull(r, magic[:])
+	if err != nil {
+		return nil, err
+	}
+
+	size := idxSize(magic[2])
+	if magic[0] != 0 || magic[1] != 0 || size == 0 || magic[3] == 0 {
+		return nil, errors.New("gosom: invalid idx magic")
+	}
+
+	dims := make([]uint32, magic[3])
+	err = binary.Read(r, binary.BigEndian, dims)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := int(dims[0])
+	columns := 1
+	for _, d := range dims[1:] {
+		// checking each factor keeps the product from overflowing
+		if d == 0 || uint64(d) > maxIDXColumns || columns*int(d) > maxIDXColumns {
+			return nil, fmt.Errorf("gosom: invalid idx dimensions %v", dims)
+		}
+
+		columns *= int(d)
+	}
+
+	if rows == 0 {
+		return nil, errors.New("gosom: no rows")
+	}
+
+	values := make([][]float64, 0, min(rows, 1024))
+	buf := make([]byte, columns*size)
+
+	for i := 0; i < rows; i++ {
+		_, err = io.ReadFull(r, buf)
+		if err != nil {
+			return nil, err
+		}
+
+		row := make([]float64, columns)
+
+		for j := range row {
+			row[j] = idxValue(magic[2], buf[j*size:])
+		}
+
+		values = append(values, row)
+	}
+
+	return NewMatrix(values), nil
+}
+
+// LoadMatrixFromIDXWithLabels reads an IDX file of images and an IDX file of
+// labels and returns a matrix with the images and the labels. If appendLabels
+// is set the labels are also added as the last column of the matrix.
+func LoadMatrixFromIDXWithLabels(images, labels io.Reader, appendLabels bool) (*Matrix, []float64, error) {
+	data, err := LoadMatrixFromIDX(images)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	labelMatrix, err := LoadMatrixFromIDX(labels)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if labelMatrix.Rows != data.Rows || labelMatrix.Columns != 1 {
+		return nil, nil, fmt.Errorf("gosom: expected %d labels but got %dx%d", data.Rows, labelMatrix.Rows, labelMatrix.Columns)
+	}
+
+	values := labelMatrix.Column(0)
+
+	if !appendLabels {
+		return data, values, nil
+	}
+
+	rows := make([][]float64, data.Rows)
+
+	for i, row := range data.Data {
+		rows[i] = make([]float64, len(row)+1)
+		copy(rows[i], row)
+		rows[i][len(row)] = values[i]
+	}
+
+	return NewMatrix(rows), values, nil
+}
+
+// idxReader returns a reader that decompresses gzip streams.
+func idxReader(source io.Reader) (io.Reader, error) {
+	r := bufio.NewReader(source)
+
+	header, err := r.Peek(2)
+	if err == nil && header[0] == 0x1f && header[1] == 0x8b {
+		return gzip.NewReader(r)
+	}
+
+	return r, nil
+}
+
+// idxSize returns the size of the IDX data type or zero if it is unknown.
+func idxSize(t byte) int {
+	switch t {
+	case 0x08, 0x09:
+		return 1
+	case 0x0B:
+		return 2
+	case 0x0C, 0x0D:
+		return 4
+	case 0x0E:
+		return 8
+	}
+
+	return 0
+}
+
+// idxValue decodes the big-endian IDX value of the type.
+func idxValue(t byte, buf []byte) float64 {
+	switch t {
+	case 0x08:
+		return float64(buf[0])
+	case 0x09:
+		return float64(int8(buf[0]))
+	case 0x0B:
+		return float64(int16(binary.BigEndian.Uint16(buf)))
+	case 0x0C:
+		return float64(int32(binary.BigEndian.Uint32(buf)))
+	case 0x0D:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(buf)))
+	case 0x0E:
+		return math.Float64frombits(binary.BigEndian.Uint64(buf))
+	}
+
+	return math.NaN()
+}
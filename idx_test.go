@@ -0,0 +1,78 @@
+package gosom
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var idxImages = []byte{
+	0, 0, 0x08, 3, // unsigned bytes with three dimensions
+	0, 0, 0, 2, 0, 0, 0, 2, 0, 0, 0, 1, // 2x2x1
+	1, 2,
+	3, 255,
+}
+
+var idxLabels = []byte{
+	0, 0, 0x08, 1,
+	0, 0, 0, 2,
+	7, 9,
+}
+
+func TestLoadMatrixFromIDX(t *testing.T) {
+	assert.True(t, IsIDX(idxImages))
+	assert.False(t, IsIDX([]byte("1,2,3")))
+
+	m, err := LoadMatrixFromIDX(bytes.NewReader(idxImages))
+	require.NoError(t, err)
+	assert.Equal(t, [][]float64{{1, 2}, {3, 255}}, m.Data)
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write(idxImages)
+	w.Close()
+
+	assert.False(t, IsIDX(buf.Bytes()))
+
+	m, err = LoadMatrixFromIDX(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, [][]float64{{1, 2}, {3, 255}}, m.Data)
+
+	_, err = LoadMatrixFromIDX(bytes.NewReader(idxImages[:18]))
+	assert.Error(t, err)
+}
+
+func TestLoadMatrixFromIDXInvalidHeader(t *testing.T) {
+	for _, header := range [][]byte{
+		{0, 0, 0x08, 2, 0, 0, 0, 0, 0, 0, 0, 1},                                     // no rows
+		{0, 0, 0x08, 2, 0, 0, 0, 1, 0, 0, 0, 0},                                     // no columns
+		{0, 0, 0x08, 2, 0xff, 0xff, 0xff, 0xff, 0x10, 0, 0, 0},                      // too many columns
+		{0, 0, 0x08, 3, 0, 0, 0, 1, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, // overflow
+	} {
+		_, err := LoadMatrixFromIDX(bytes.NewReader(header))
+		assert.Error(t, err)
+	}
+
+	// large row counts fail on the missing data instead of allocating
+	_, err := LoadMatrixFromIDX(bytes.NewReader([]byte{0, 0, 0x08, 2, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 1, 1}))
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestLoadMatrixFromIDXWithLabels(t *testing.T) {
+	m, labels, err := LoadMatrixFromIDXWithLabels(bytes.NewReader(idxImages), bytes.NewReader(idxLabels), false)
+	require.NoError(t, err)
+	assert.Equal(t, [][]float64{{1, 2}, {3, 255}}, m.Data)
+	assert.Equal(t, []float64{7, 9}, labels)
+
+	m, labels, err = LoadMatrixFromIDXWithLabels(bytes.NewReader(idxImages), bytes.NewReader(idxLabels), true)
+	require.NoError(t, err)
+	assert.Equal(t, [][]float64{{1, 2, 7}, {3, 255, 9}}, m.Data)
+	assert.Equal(t, []float64{7, 9}, labels)
+
+	_, _, err = LoadMatrixFromIDXWithLabels(bytes.NewReader(idxImages), bytes.NewReader(idxLabels[:9]), true)
+	assert.Error(t, err)
+}
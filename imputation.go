@@ -0,0 +1,116 @@
+package gosom
+
+import "math"
+
+// An Imputer replaces missing values (NaNs) using a trained SOM.
+type Imputer struct {
+	SOM    *SOM
+	Method string
+	K      int
+
+	minimums []float64
+	maximums []float64
+}
+
+// NewImputer returns a new Imputer that uses the specified method ("bmu",
+// "knn" or "weighted") and K nearest neighbors. K is clamped to the number of
+// nodes.
+func NewImputer(som *SOM, method string, K int) *Imputer {
+	weights := som.WeightMatrix()
+
+	return &Imputer{
+		SOM:      som,
+		Method:   method,
+		K:        max(1, min(K, len(som.Nodes))),
+		minimums: weights.Minimums,
+		maximums: weights.Maximums,
+	}
+}
+
+// Estimate returns the full estimate for the input based on the selected
+// method. Unknown methods return nil.
+func (i *Imputer) Estimate(input []float64) []float64 {
+	switch i.Method {
+	case "bmu":
+		return i.SOM.Classify(input)
+	case "knn":
+		return i.SOM.Interpolate(input, i.K)
+	case "weighted":
+		return i.SOM.WeightedInterpolate(input, i.K)
+	}
+
+	return nil
+}
+
+// Row returns a copy of the row in which all missing values have been
+// replaced and the confidence [0..1] for each cell. Observed values are left
+// unchanged and have a confidence of one. The confidence of an imputed value
+// is derived from the agreement of the K nearest nodes in that dimension.
+//
+// Note: Missing values are left untouched if the method is unknown.
+func (i *Imputer) Row(row []float64) ([]float64, []float64) {
+	values := make([]float64, len(row))
+	confidence := make([]float64, len(row))
+	copy(values, row)
+
+	var estimate []float64
+	var neighbors []*Node
+
+	for j, value := range row {
+		confidence[j] = 1.0
+
+		if !math.IsNaN(value) {
+			continue
+		}
+
+		if estimate == nil {
+			estimate = i.Estimate(row)
+			neighbors = i.SOM.Neighbors(row, i.K)
+		}
+
+		if estimate == nil || j >= len(estimate) {
+			confidence[j] = 0.0
+			continue
+		}
+
+		values[j] = estimate[j]
+		confidence[j] = i.confidence(neighbors, j)
+	}
+
+	return values, confidence
+}
+
+// Matrix returns a copy of data with all missing values replaced and a
+// matrix holding the confidence of each cell.
+func (i *Imputer) Matrix(data *Matrix) (*Matrix, *Matrix) {
+	values := make([][]float64, data.Rows)
+	confidence := make([][]float64, data.Rows)
+
+	for j, row := range data.Data {
+		values[j], confidence[j] = i.Row(row)
+	}
+
+	return NewMatrix(values), NewMatrix(confidence)
+}
+
+func (i *Imputer) confidence(neighbors []*Node, dimension int) float64 {
+	r := i.maximums[dimension] - i.minimums[dimension]
+	if r == 0 {
+		return 1.0
+	}
+
+	column := make([]float64, len(neighbors))
+	for j, node := range neighbors {
+		column[j] = node.Weights[dimension]
+	}
+
+	return math.Max(0.0, 1.0-stdDev(column)/r)
+}
+
+// Impute returns a copy of data in which all missing values (NaNs) have been
+// replaced using the specified method ("bmu", "knn" or "weighted") and K
+// nearest neighbors. Observed values are left unchanged.
+func (som *SOM) Impute(data *Matrix, method string, K int) *Matrix {
+	values, _ := NewImputer(som, method, K).Matrix(data)
+	return values
+}
@@ -0,0 +1,69 @@
+package gosom
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func imputationSOM() *SOM {
+	som := NewSOM(3, 1)
+	som.Nodes = NewLattice(3, 1, 2)
+	som.Nodes[0].Weights = []float64{0.0, 0.0}
+	som.Nodes[1].Weights = []float64{1.0, 2.0}
+	som.Nodes[2].Weights = []float64{2.0, 4.0}
+
+	return som
+}
+
+func TestImputerRow(t *testing.T) {
+	imputer := NewImputer(imputationSOM(), "bmu", 1)
+
+	values, confidence := imputer.Row([]float64{1.1, math.NaN()})
+	assert.Equal(t, []float64{1.1, 2.0}, values)
+	assert.Equal(t, []float64{1.0, 1.0}, confidence)
+}
+
+func TestImputerRowKNN(t *testing.T) {
+	imputer := NewImputer(imputationSOM(), "knn", 2)
+
+	values, confidence := imputer.Row([]float64{0.4, math.NaN()})
+	assert.Equal(t, []float64{0.4, 1.0}, values)
+	assert.Equal(t, []float64{1.0, 0.75}, confidence)
+}
+
+func TestImputerClampsK(t *testing.T) {
+	imputer := NewImputer(imputationSOM(), "knn", 10)
+	assert.Equal(t, 3, imputer.K)
+
+	values, _ := imputer.Row([]float64{0.4, math.NaN()})
+	assert.Equal(t, []float64{0.4, 2.0}, values)
+
+	values, _ = NewImputer(imputationSOM(), "weighted", 10).Row([]float64{0.4, math.NaN()})
+	assert.False(t, math.IsNaN(values[1]))
+}
+
+func TestImputerRowUnknownMethod(t *testing.T) {
+	imputer := NewImputer(imputationSOM(), "foo", 1)
+
+	values, confidence := imputer.Row([]float64{1.0, math.NaN()})
+	assert.True(t, math.IsNaN(values[1]))
+	assert.Equal(t, []float64{1.0, 0.0}, confidence)
+}
+
+func TestImpute(t *testing.T) {
+	data := NewMatrix([][]float64{
+		{math.NaN(), 4.1},
+		{0.1, math.NaN()},
+		{1.5, 2.5},
+	})
+
+	m := imputationSOM().Impute(data, "bmu", 1)
+	assert.Equal(t, [][]float64{
+		{2.0, 4.1},
+		{0.1, 0.0},
+		{1.5, 2.5},
+	}, m.Data)
+	assert.False(t, m.NaNs)
+}
@@ -0,0 +1,229 @@
+package gosom
+
+import (
+	"math"
+	"sync"
+
+	"github.com/256dpi/gosom/functions"
+)
+
+// resolved holds the functions resolved from the names of a SOM. Entries are
+// shared by all SOMs that use the same names.
+type resolved struct {
+	coolingName      string
+	distanceName     string
+	neighborhoodName string
+
+	cooling      functions.CoolingFunction
+	distance     functions.DistanceFunction
+	distance32   functions.DistanceFunction32
+	neighborhood functions.NeighborhoodFunction
+
+	// kernel orders distances like distance but may skip steps that do not
+	// change the order, e.g. the square root of the euclidean distance
+	kernel functions.DistanceFunction
+}
+
+var resolvedCache = map[[3]string]*resolved{}
+var resolvedMutex sync.Mutex
+
+// functions returns the resolved functions of the SOM. They are resolved
+// again if the names have changed since the last call. The entry is swapped
+// atomically so that concurrent searches on a shared SOM are safe.
+func (som *SOM) functions() *resolved {
+	r := som.resolved.Load()
+	if r != nil && r.distanceName == som.DistanceFunction &&
+		r.neighborhoodName == som.NeighborhoodFunction && r.coolingName == som.CoolingFunction {
+		return r
+	}
+
+	r = resolve(som.CoolingFunction, som.DistanceFunction, som.NeighborhoodFunction)
+	som.resolved.Store(r)
+
+	return r
+}
+
+// resolve returns the shared entry for the names. Unknown names resolve to
+// functions that return zero.
+func resolve(cooling, distance, neighborhood string) *resolved {
+	resolvedMutex.Lock()
+	defer resolvedMutex.Unlock()
+
+	key := [3]string{cooling, distance, neighborhood}
+	if r, ok := resolvedCache[key]; ok {
+		return r
+	}
+
+	r := &resolved{
+		coolingName:      cooling,
+		distanceName:     distance,
+		neighborhoodName: neighborhood,
+		cooling:          functions.LookupCooling(cooling),
+		distance:         functions.LookupDistance(distance),
+		distance32:       functions.LookupDistance32(distance),
+		neighborhood:     functions.LookupNeighborhood(neighborhood),
+	}
+
+	if r.cooling == nil {
+		r.cooling = func(float64) float64 { return 0 }
+	}
+
+	if r.distance == nil {
+		r.distance = func(_, _ []float64) float64 { return 0 }
+	}
+
+	if r.distance32 == nil {
+		r.distance32 = func(_, _ []float32) float32 { return 0 }
+	}
+
+	if r.neighborhood == nil {
+		r.neighborhood = func(float64) float64 { return 0 }
+	}
+
+	switch distance {
+	case "euclidean":
+		r.kernel = squaredEuclideanDistance
+	case "manhattan":
+		r.kernel = manhattanKernel
+	case "cosine":
+		r.kernel = cosineKernel
+	default:
+		r.kernel = r.distance
+	}
+
+	resolvedCache[key] = r
+
+	return r
+}
+
+// squaredEuclideanDistance returns the squared euclidean distance between two
+// points.
+//
+// Note: Dimensions that include NaNs are ignored.
+func squaredEuclideanDistance(from, to []float64) float64 {
+	d := 0.0
+	l := min(len(from), len(to))
+
+	for i := 0; i < l; i++ {
+		if math.IsNaN(from[i]) || math.IsNaN(to[i]) {
+			continue
+		}
+
+		d += (from[i] - to[i]) * (from[i] - to[i])
+	}
+
+	return d
+}
+
+// manhattanKernel returns the manhattan distance between two points without
+// calling into the math package.
+//
+// Note: Dimensions that include NaNs are ignored.
+func manhattanKernel(from, to []float64) float64 {
+	d := 0.0
+	l := min(len(from), len(to))
+
+	for i := 0; i < l; i++ {
+		if from[i] != from[i] || to[i] != to[i] {
+			continue
+		}
+
+		if to[i] > from[i] {
+			d += to[i] - from[i]
+		} else {
+			d += from[i] - to[i]
+		}
+	}
+
+	return d
+}
+
+// cosineKernel returns the negated signed square of the cosine similarity
+// between two points. It orders like the cosine distance without taking
+// square roots and is zero if one of the points is a zero vector.
+//
+// Note: Dimensions that include NaNs are ignored.
+func cosineKernel(from, to []float64) float64 {
+	dot, a, b := 0.0, 0.0, 0.0
+	l := min(len(from), len(to))
+
+	for i := 0; i < l; i++ {
+		if from[i] != from[i] || to[i] != to[i] {
+			continue
+		}
+
+		dot += from[i] * to[i]
+		a += from[i] * from[i]
+		b += to[i] * to[i]
+	}
+
+	if a == 0 || b == 0 {
+		return 0
+	}
+
+	// divide before multiplying so that large weights do not overflow
+	return -(dot / a) * (math.Abs(dot) / b)
+}
+
+// bmu returns the index of the smallest distance of n candidates without
+// allocating. The result matches a search that starts with a random candidate
+// and collects all candidates with the smallest distance including the start
+// candidate again. Ties are broken by drawing once from that collection.
+func bmu(n int, distance func(i int) float64, intn func(int) int) int {
+	// select random to start with
+	start := intn(n)
+	t := distance(start)
+
+	// the start is kept if no distance compares to it, e.g. if it is NaN
+	keep := true
+	first := start
+	ties := 0
+
+	for i := 0; i < n; i++ {
+		d := distance(i)
+
+		if d < t {
+			t = d
+			keep = false
+			first = i
+			ties = 1
+		} else if d == t {
+			if ties == 0 {
+				first = i
+			}
+
+			ties++
+		}
+	}
+
+	total := ties
+	if keep {
+		total++
+	}
+
+	if total == 1 {
+		return first
+	}
+
+	// draw a random winner
+	k := intn(total)
+	if keep {
+		if k == 0 {
+			return start
+		}
+
+		k--
+	}
+
+	for i := first; i < n; i++ {
+		if distance(i) == t {
+			if k == 0 {
+				return i
+			}
+
+			k--
+		}
+	}
+
+	return first
+}
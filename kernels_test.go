@@ -0,0 +1,154 @@
+package gosom
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFunctionCache(t *testing.T) {
+	som := NewSOM(1, 1)
+	assert.Equal(t, math.Sqrt(2), som.D([]float64{0, 0}, []float64{1, 1}))
+	assert.Equal(t, 1.0, som.NI(0))
+	assert.Equal(t, 1.0, som.CF(0))
+
+	som.DistanceFunction = "manhattan"
+	som.NeighborhoodFunction = "bubble"
+	som.CoolingFunction = "foo"
+	assert.Equal(t, 2.0, som.D([]float64{0, 0}, []float64{1, 1}))
+	assert.Equal(t, 1.0, som.NI(0.5))
+	assert.Equal(t, 0.0, som.CF(0))
+
+	other := NewSOM(2, 2)
+	other.DistanceFunction = "manhattan"
+	other.NeighborhoodFunction = "bubble"
+	other.CoolingFunction = "foo"
+	other.D(nil, nil)
+	assert.True(t, som.resolved.Load() == other.resolved.Load())
+
+	som.DistanceFunction = "foo"
+	assert.Equal(t, 0.0, som.D([]float64{0, 0}, []float64{1, 1}))
+}
+
+// referenceBMU is the search that bmu replaces.
+func referenceBMU(distances []float64, intn func(int) int) int {
+	r := intn(len(distances))
+	t := distances[r]
+	nodes := []int{r}
+
+	for i, d := range distances {
+		if d < t {
+			t = d
+			nodes = append([]int{}, i)
+		} else if d == t {
+			nodes = append(nodes, i)
+		}
+	}
+
+	if len(nodes) > 1 {
+		return nodes[intn(len(nodes))]
+	}
+
+	return nodes[0]
+}
+
+func TestBMU(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 1000; i++ {
+		distances := make([]float64, 1+r.Intn(8))
+		for j := range distances {
+			switch r.Intn(8) {
+			case 0:
+				distances[j] = math.NaN()
+			case 1:
+				distances[j] = math.Inf(1)
+			default:
+				distances[j] = float64(r.Intn(3))
+			}
+		}
+
+		seed := r.Int63()
+		r1 := rand.New(rand.NewSource(seed))
+		r2 := rand.New(rand.NewSource(seed))
+
+		assert.Equal(t, referenceBMU(distances, r1.Intn), bmu(len(distances), func(i int) float64 {
+			return distances[i]
+		}, r2.Intn), distances)
+		assert.Equal(t, r1.Int63(), r2.Int63())
+	}
+}
+
+func TestClosestNaN(t *testing.T) {
+	som := NewSOM(2, 1)
+	som.InitializeWithZeroes(1)
+	som.Nodes[0].Weights[0] = math.Inf(1)
+	som.Nodes[1].Weights[0] = math.Inf(1)
+
+	// inf - inf is NaN and the search keeps the random start
+	for i := 0; i < 10; i++ {
+		assert.NotNil(t, som.Closest([]float64{math.Inf(1)}))
+	}
+
+	assert.Equal(t, 1, bmu(3, func(int) float64 {
+		return math.NaN()
+	}, func(int) int {
+		return 1
+	}))
+}
+
+func TestKernels(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, distance := range []string{"euclidean", "manhattan", "cosine"} {
+		som := NewSOM(1, 1)
+		som.DistanceFunction = distance
+		kernel := som.functions().kernel
+		assert.NotNil(t, kernel)
+
+		for i := 0; i < 1000; i++ {
+			input := []float64{r.NormFloat64(), r.NormFloat64(), math.NaN()}
+			a := []float64{r.NormFloat64(), r.NormFloat64(), r.NormFloat64()}
+			b := []float64{r.NormFloat64(), r.NormFloat64(), r.NormFloat64()}
+
+			assert.Equal(t, som.D(input, a) < som.D(input, b), kernel(input, a) < kernel(input, b), distance)
+		}
+
+		assert.Equal(t, som.D([]float64{1, 2}, []float64{3, 4}) < som.D([]float64{1, 2}, []float64{0, 0}),
+			kernel([]float64{1, 2}, []float64{3, 4}) < kernel([]float64{1, 2}, []float64{0, 0}), distance)
+	}
+}
+
+func TestFunctionsConcurrent(t *testing.T) {
+	som := NewSOM(2, 2)
+	som.InitializeWithZeroes(2)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			som.Closest([]float64{1, 1})
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestClosestAllocations(t *testing.T) {
+	som, data := benchmarkSOM(true)
+
+	for _, distance := range []string{"euclidean", "manhattan", "cosine"} {
+		som.DistanceFunction = distance
+		som.Closest(data.Data[0])
+
+		assert.Zero(t, testing.AllocsPerRun(10, func() {
+			som.Closest(data.Data[1])
+		}), distance)
+	}
+}
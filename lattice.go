@@ -1,24 +1,89 @@
 package gosom
 
-import "sort"
+import (
+	"encoding/json"
+	"sort"
+)
 
 // A Lattice is a collection of nodes arranged in a two dimensional space.
+//
+// The weights of a lattice returned by NewLattice are stored in one contiguous
+// buffer in row-major order (node × dimension) and the nodes hold views into
+// that buffer. The views are capped so that appending to the weights of a node
+// copies them instead of overwriting the next node.
 type Lattice []*Node
 
 // NewLattice returns an initialized lattice.
 func NewLattice(width, height, dimensions int) Lattice {
+	nodes := make([]Node, width*height)
+	positions := make([]float64, width*height*2)
+	weights := make([]float64, width*height*dimensions)
 	lattice := make(Lattice, width*height)
 
 	for i := 0; i < height; i++ {
 		for j := 0; j < width; j++ {
 			k := i*width + j
-			lattice[k] = NewNode(j, i, dimensions)
+
+			nodes[k].Position = positions[k*2 : k*2+2 : k*2+2]
+			nodes[k].Position[0] = float64(j)
+			nodes[k].Position[1] = float64(i)
+			nodes[k].Weights = weights[k*dimensions : (k+1)*dimensions : (k+1)*dimensions]
+
+			lattice[k] = &nodes[k]
 		}
 	}
 
 	return lattice
 }
 
+// UnmarshalJSON implements the json.Unmarshaler interface and stores the
+// decoded weights in a contiguous buffer.
+func (l *Lattice) UnmarshalJSON(data []byte) error {
+	var nodes []*Node
+
+	err := json.Unmarshal(data, &nodes)
+	if err != nil {
+		return err
+	}
+
+	*l = Lattice(nodes).compact()
+
+	return nil
+}
+
+// compact returns a copy of the lattice that stores the weights in a
+// contiguous buffer. The lattice is returned as is if the nodes differ in
+// their dimensions.
+func (l Lattice) compact() Lattice {
+	if len(l) == 0 || l[0] == nil {
+		return l
+	}
+
+	dimensions := len(l[0].Weights)
+
+	for _, node := range l {
+		if node == nil || len(node.Weights) != dimensions || len(node.Position) != 2 {
+			return l
+		}
+	}
+
+	nodes := make([]Node, len(l))
+	positions := make([]float64, len(l)*2)
+	weights := make([]float64, len(l)*dimensions)
+	lattice := make(Lattice, len(l))
+
+	for k, node := range l {
+		nodes[k].Position = positions[k*2 : k*2+2 : k*2+2]
+		copy(nodes[k].Position, node.Position)
+		nodes[k].Weights = weights[k*dimensions : (k+1)*dimensions : (k+1)*dimensions]
+		copy(nodes[k].Weights, node.Weights)
+
+		lattice[k] = &nodes[k]
+	}
+
+	return lattice
+}
+
 // Sort returns a sorted lattice using the sorting function.
 func (l Lattice) Sort(sortFunction func(n1, n2 *Node) bool) Lattice {
 	cpy := make(Lattice, len(l))
@@ -1,9 +1,11 @@
 package gosom
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewLattice(t *testing.T) {
@@ -39,3 +41,26 @@ func TestLatticeSorting(t *testing.T) {
 	assert.Equal(t, 0.0, l3[0].Weights[0])
 	assert.Equal(t, 3.0, l3[3].Weights[0])
 }
+
+func TestLatticeWeights(t *testing.T) {
+	l := NewLattice(2, 2, 2)
+
+	for i, node := range l {
+		node.Weights[0] = float64(i)
+		node.Weights[1] = float64(i) / 2
+		assert.Equal(t, 2, cap(node.Weights))
+	}
+
+	buf, err := json.Marshal(l)
+	require.NoError(t, err)
+
+	var l2 Lattice
+	err = json.Unmarshal(buf, &l2)
+	require.NoError(t, err)
+	assert.Equal(t, l, l2)
+	assert.Equal(t, 2, cap(l2[0].Weights))
+
+	l[0].Weights = append(l[0].Weights, 9)
+	assert.Equal(t, []float64{0, 0, 9}, l[0].Weights)
+	assert.Equal(t, []float64{1, 0.5}, l[1].Weights)
+}
@@ -1,12 +1,10 @@
 package gosom
 
 import (
-	"encoding/csv"
 	"encoding/json"
 	"io"
 	"math"
 	"math/rand"
-	"strconv"
 
 	"github.com/gonum/floats"
 )
@@ -81,31 +79,12 @@ func (m *Matrix) SubMatrix(start, length int) *Matrix {
 	return NewMatrix(values)
 }
 
-// LoadMatrixFromCSV reads CSV data and returns a new matrix.
+// LoadMatrixFromCSV reads CSV data and returns a new matrix. Values that are
+// not numbers are read as NaNs. Use LoadMatrixFromCSVWithOptions to configure
+// the parsing.
 func LoadMatrixFromCSV(source io.Reader) (*Matrix, error) {
-	reader := csv.NewReader(source)
-
-	data, err := reader.ReadAll()
-	if err != nil {
-		return nil, err
-	}
-
-	values := make([][]float64, len(data))
-
-	for i, row := range data {
-		values[i] = make([]float64, len(row))
-
-		for j, value := range row {
-			f, err := strconv.ParseFloat(value, 64)
-			if err != nil {
-				values[i][j] = math.NaN()
-			} else {
-				values[i][j] = f
-			}
-		}
-	}
-
-	return NewMatrix(values), nil
+	m, _, err := LoadMatrixFromCSVWithOptions(source, CSVOptions{})
+	return m, err
 }
 
 // LoadMatrixFromJSON read JSON data and returns a new matrix.
@@ -0,0 +1,252 @@
+package gosom
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// ModelVersion is the current schema version of the model envelope.
+const ModelVersion = 1
+
+// A Model wraps a SOM in an envelope with metadata about its origin.
+type Model struct {
+	// The schema version of the envelope.
+	Version int
+
+	// The time the model was created.
+	Created time.Time
+
+	// The names of the dimensions.
+	Names []string `json:",omitempty"`
+
+	// The training runs that have been applied to the SOM.
+	Runs []TrainingRun `json:",omitempty"`
+
+	// The state of an unfinished training.
+	Checkpoint *TrainingState `json:",omitempty"`
+
+	// The SHA-256 hash of the envelope that is verified on load.
+	Checksum string
+
+	SOM *SOM
+}
+
+// A TrainingRun records the parameters of a training.
+type TrainingRun struct {
+	Time                 time.Time
+	Steps                int
+	InitialLearningRate  float64
+	FinalLearningRate    float64
+	InitialRadius        float64
+	FinalRadius          float64
+	CoolingFunction      string
+	DistanceFunction     string
+	NeighborhoodFunction string
+	Topology             string
+	Precision            int `json:",omitempty"`
+	Seed                 int64
+	Fingerprint          string
+}
+
+// NewModel returns a new Model for the SOM.
+func NewModel(som *SOM) *Model {
+	return &Model{
+		Version: ModelVersion,
+		Created: time.Now().UTC(),
+		SOM:     som,
+	}
+}
+
+// AddRun records a training with the data and the seed of the random number
+// generator.
+func (m *Model) AddRun(training *Training, data *Matrix, seed int64) {
+	m.addRun(training, Fingerprint(data), seed)
+}
+
+// AddSparseRun records a training run on sparse data.
+func (m *Model) AddSparseRun(training *Training, data *SparseMatrix, seed int64) {
+	m.addRun(training, SparseFingerprint(data), seed)
+}
+
+func (m *Model) addRun(training *Training, fingerprint string, seed int64) {
+	m.Runs = append(m.Runs, TrainingRun{
+		Time:                 time.Now().UTC(),
+		Steps:                training.Steps,
+		InitialLearningRate:  training.InitialLearningRate,
+		FinalLearningRate:    training.FinalLearningRate,
+		InitialRadius:        training.InitialRadius,
+		FinalRadius:          training.FinalRadius,
+		CoolingFunction:      m.SOM.CoolingFunction,
+		DistanceFunction:     m.SOM.DistanceFunction,
+		NeighborhoodFunction: m.SOM.NeighborhoodFunction,
+		Topology:             m.SOM.Topology,
+		Precision:            m.SOM.Precision,
+		Seed:                 seed,
+		Fingerprint:          fingerprint,
+	})
+}
+
+// Save writes the model with an updated checksum as JSON to destination.
+func (m *Model) Save(destination io.Writer) error {
+	checksum, err := m.checksum()
+	if err != nil {
+		return err
+	}
+
+	m.Checksum = checksum
+
+	writer := json.NewEncoder(destination)
+	return writer.Encode(m)
+}
+
+// LoadModel reads a model from source and verifies its checksum. Plain JSON
+// and binary SOMs are migrated to a model without metadata.
+func LoadModel(source io.Reader) (*Model, error) {
+	buf, err := io.ReadAll(source)
+	if err != nil {
+		return nil, err
+	}
+
+	if IsBinary(buf) {
+		som, err := LoadBinary(bytes.NewReader(buf))
+		if err != nil {
+			return nil, err
+		}
+
+		return migrateModel(som), nil
+	}
+
+	var header struct {
+		Version int
+	}
+
+	err = json.Unmarshal(buf, &header)
+	if err != nil {
+		return nil, err
+	}
+
+	// plain SOMs have no version
+	if header.Version == 0 {
+		som, err := LoadSOMFromJSON(bytes.NewReader(buf))
+		if err != nil {
+			return nil, err
+		}
+
+		return migrateModel(som), nil
+	}
+
+	if header.Version > ModelVersion {
+		return nil, fmt.Errorf("gosom: unsupported model version %d", header.Version)
+	}
+
+	m := &Model{}
+
+	err = json.Unmarshal(buf, m)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.SOM == nil {
+		return nil, errors.New("gosom: model without SOM")
+	}
+
+	if m.SOM.Detector != nil {
+		m.SOM.Detector.SOM = m.SOM
+	}
+
+	checksum, err := m.checksum()
+	if err != nil {
+		return nil, err
+	}
+
+	if checksum != m.Checksum {
+		return nil, errors.New("gosom: model checksum mismatch")
+	}
+
+	return m, nil
+}
+
+// migrateModel wraps a SOM without metadata in a model.
+func migrateModel(som *SOM) *Model {
+	return &Model{
+		Version: ModelVersion,
+		SOM:     som,
+	}
+}
+
+// checksum returns the hash of the JSON encoded model without its checksum.
+func (m *Model) checksum() (string, error) {
+	cpy := *m
+	cpy.Checksum = ""
+
+	buf, err := json.Marshal(&cpy)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Fingerprint returns the SHA-256 hash of the values in data.
+func Fingerprint(data *Matrix) string {
+	hash := sha256.New()
+	buf := make([]byte, 8)
+
+	binary.LittleEndian.PutUint64(buf, uint64(data.Columns))
+	hash.Write(buf)
+
+	for _, row := range data.Data {
+		for _, value := range row {
+			binary.LittleEndian.PutUint64(buf, math.Float64bits(value))
+			hash.Write(buf)
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// SparseFingerprint returns the SHA-256 hash of the non-zero values and their
+// indexes in data. Its cost depends on the number of non-zero values only.
+//
+// Note: The fingerprint differs from the fingerprint of the dense matrix.
+func SparseFingerprint(data *SparseMatrix) string {
+	hash := sha256.New()
+	buf := make([]byte, 8)
+
+	write := func(v uint64) {
+		binary.LittleEndian.PutUint64(buf, v)
+		hash.Write(buf)
+	}
+
+	write(uint64(data.Columns))
+
+	for _, row := range data.Data {
+		// the count separates the rows
+		count := 0
+		for _, value := range row.Values {
+			if value != 0 {
+				count++
+			}
+		}
+
+		write(uint64(count))
+
+		for k, index := range row.Indices {
+			if row.Values[k] != 0 {
+				write(uint64(index))
+				write(math.Float64bits(row.Values[k]))
+			}
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
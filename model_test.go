@@ -0,0 +1,82 @@
+package gosom
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModel(t *testing.T) {
+	data := NewMatrix([][]float64{{0, 0}, {1, 1}})
+
+	som := NewSOM(2, 2)
+	som.InitializeWithRandomValues(data)
+	NewDetector(som, false)
+
+	model := NewModel(som)
+	model.Names = []string{"a", "b"}
+	model.AddRun(NewTraining(som, 10, 0.5, 0.1, 1, 0), data, 42)
+
+	var buf bytes.Buffer
+	require.NoError(t, model.Save(&buf))
+	assert.Len(t, model.Checksum, 64)
+
+	loaded, err := LoadModel(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, model.Created, loaded.Created)
+	assert.Equal(t, model.Runs, loaded.Runs)
+	assert.Equal(t, []string{"a", "b"}, loaded.Names)
+	assert.Equal(t, int64(42), loaded.Runs[0].Seed)
+	assert.Equal(t, "rectangular", loaded.Runs[0].Topology)
+	assert.Equal(t, 0, loaded.Runs[0].Precision)
+	assert.Equal(t, som.Nodes, loaded.SOM.Nodes)
+	assert.Equal(t, loaded.SOM, loaded.SOM.Detector.SOM)
+}
+
+func TestModelChecksum(t *testing.T) {
+	som := NewSOM(1, 1)
+	som.InitializeWithZeroes(1)
+
+	var buf bytes.Buffer
+	require.NoError(t, NewModel(som).Save(&buf))
+
+	tampered := strings.Replace(buf.String(), `"Weights":[0]`, `"Weights":[1]`, 1)
+	require.NotEqual(t, buf.String(), tampered)
+
+	_, err := LoadModel(strings.NewReader(tampered))
+	assert.Error(t, err)
+}
+
+func TestModelMigration(t *testing.T) {
+	som := NewSOM(1, 1)
+	som.InitializeWithZeroes(1)
+
+	var buf bytes.Buffer
+	require.NoError(t, som.SaveAsJSON(&buf))
+
+	model, err := LoadModel(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, ModelVersion, model.Version)
+	assert.Equal(t, som, model.SOM)
+
+	buf.Reset()
+	require.NoError(t, som.SaveBinary(&buf, 64))
+
+	model, err = LoadModel(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, som, model.SOM)
+
+	_, err = LoadModel(strings.NewReader(`{"Version":99}`))
+	assert.Error(t, err)
+}
+
+func TestFingerprint(t *testing.T) {
+	a := NewMatrix([][]float64{{0, 1}, {2, 3}})
+	b := NewMatrix([][]float64{{0, 1, 2, 3}})
+
+	assert.Equal(t, Fingerprint(a), Fingerprint(NewMatrix([][]float64{{0, 1}, {2, 3}})))
+	assert.NotEqual(t, Fingerprint(a), Fingerprint(b))
+}
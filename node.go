@@ -3,6 +3,9 @@ package gosom
 import "math"
 
 // A Node is a single neuron in a self organizing map.
+//
+// Note: The weights of nodes in a lattice are views into a shared buffer.
+// Appending to them copies the weights and detaches the node from the buffer.
 type Node struct {
 	Position []float64
 	Weights  []float64
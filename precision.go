@@ -0,0 +1,80 @@
+package gosom
+
+// single returns the single precision weights of the SOM if it uses a
+// precision of 32 bits and nil otherwise. The weights are copied at the first
+// step of a run, when the nodes have been replaced or on the first call and
+// the node weights are rounded to match them.
+//
+// Note: Changes to the node weights in the middle of a run are not picked up.
+func (t *Training) single(som *SOM, step int) []float32 {
+	if som.Precision != 32 {
+		t.weights = nil
+		t.nodes = nil
+		return nil
+	}
+
+	dimensions := som.Dimensions()
+
+	if step == 0 || t.nodes != som.Nodes[0] || len(t.weights) != len(som.Nodes)*dimensions {
+		if len(t.weights) != len(som.Nodes)*dimensions {
+			t.weights = make([]float32, len(som.Nodes)*dimensions)
+		}
+
+		t.nodes = som.Nodes[0]
+
+		for i, node := range som.Nodes {
+			for j, w := range node.Weights {
+				t.weights[i*dimensions+j] = float32(w)
+				node.Weights[j] = float64(float32(w))
+			}
+		}
+	}
+
+	return t.weights
+}
+
+// convert returns the input in single precision using a reused buffer.
+func (t *Training) convert(input []float64) []float32 {
+	if cap(t.scratch) < len(input) {
+		t.scratch = make([]float32, len(input))
+	}
+
+	t.scratch = t.scratch[:len(input)]
+
+	for i, value := range input {
+		t.scratch[i] = float32(value)
+	}
+
+	return t.scratch
+}
+
+// closest32 returns the closest node to the input using the single precision
+// weights and breaks ties using the random function like closest.
+func (som *SOM) closest32(input, weights []float32, intn func(int) int) *Node {
+	distance := som.functions().distance32
+	dimensions := som.Dimensions()
+
+	i := bmu(len(som.Nodes), func(i int) float64 {
+		return float64(distance(input, weights[i*dimensions:(i+1)*dimensions]))
+	}, intn)
+
+	return som.Nodes[i]
+}
+
+// adjust32 adjusts the single precision weights of the node and stores the
+// result in the node weights.
+//
+// Note: Dimensions that include NaNs are ignored.
+func adjust32(node *Node, weights, input []float32, influence float64) {
+	l := min(len(input), len(weights))
+	h := float32(influence)
+
+	for i := 0; i < l; i++ {
+		if input[i] != input[i] {
+			continue
+		}
+
+		weights[i] += (input[i] - weights[i]) * h
+		node.Weights[i] = float64(weights[i])
+	}
+}
@@ -0,0 +1,107 @@
+package gosom
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func precisionTestData() *Matrix {
+	r := rand.New(rand.NewSource(1))
+	data := make([][]float64, 200)
+
+	for i := range data {
+		// two clusters with some noise
+		center := float64(i % 2)
+		data[i] = make([]float64, 8)
+		for j := range data[i] {
+			data[i][j] = center + r.NormFloat64()*0.1
+		}
+	}
+
+	return NewMatrix(data)
+}
+
+func TestSinglePrecision(t *testing.T) {
+	data := precisionTestData()
+
+	for _, distance := range []string{"euclidean", "manhattan", "cosine"} {
+		som64 := NewSOM(6, 6)
+		som64.DistanceFunction = distance
+		som64.InitializeWithZeroes(data.Columns)
+
+		for i, node := range som64.Nodes {
+			copy(node.Weights, data.Data[i])
+		}
+
+		som32 := NewSOM(6, 6)
+		som32.DistanceFunction = distance
+		som32.Precision = 32
+		som32.InitializeWithZeroes(data.Columns)
+
+		for i, node := range som32.Nodes {
+			copy(node.Weights, data.Data[i])
+		}
+
+		training64 := NewTraining(som64, 2000, 0.5, 0.01, 3, 0.5)
+		training64.Source = NewSource(1)
+		som64.Train(data, training64)
+
+		training32 := NewTraining(som32, 2000, 0.5, 0.01, 3, 0.5)
+		training32.Source = NewSource(1)
+		som32.Train(data, training32)
+
+		for _, node := range som32.Nodes {
+			for _, w := range node.Weights {
+				assert.Equal(t, float64(float32(w)), w, distance)
+			}
+		}
+
+		qe64 := som64.QuantizationError(data)
+		qe32 := som32.QuantizationError(data)
+		assert.InEpsilon(t, qe64, qe32, 0.05, distance)
+	}
+}
+
+func TestSinglePrecisionChangedWeights(t *testing.T) {
+	som := NewSOM(2, 1)
+	som.Precision = 32
+	som.InitializeWithZeroes(1)
+
+	data := NewMatrix([][]float64{{1}})
+	training := NewTraining(som, 1, 0.5, 0.5, 0.1, 0.1)
+	som.Train(data, training)
+
+	// the changes are picked up by the next run
+	som.Nodes[0].Weights[0] = 10
+	som.Nodes[1].Weights[0] = 10
+	som.Train(data, training)
+
+	// the winner moves halfway and the other node is out of reach
+	values := []float64{som.Nodes[0].Weights[0], som.Nodes[1].Weights[0]}
+	assert.ElementsMatch(t, []float64{5.5, 10}, values)
+}
+
+func TestAdjust32(t *testing.T) {
+	node := NewNode(0, 0, 2)
+	weights := []float32{1, 1}
+
+	adjust32(node, weights, []float32{0, float32(math.NaN())}, 0.5)
+
+	assert.Equal(t, []float32{0.5, 1}, weights)
+	assert.Equal(t, []float64{0.5, 0}, node.Weights)
+}
+
+func BenchmarkTrainSingle(b *testing.B) {
+	som, data := benchmarkSOM(true)
+	som.Precision = 32
+	training := NewTraining(som, 100, 0.5, 0.01, 25, 0.5)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		som.Train(data, training)
+	}
+}
@@ -0,0 +1,245 @@
+package gosom
+
+import "math"
+
+// MaxProjectionNodes is the number of nodes above which projections should not
+// be computed. MDS and Sammon hold dense matrices of the distances between all
+// nodes, which take about 50 MB each at this limit and grow quadratically.
+const MaxProjectionNodes = 2500
+
+// MDS projects the node weights to two dimensions using classical
+// multidimensional scaling. It returns a matrix with the coordinates of every
+// node.
+//
+// Note: The memory grows quadratically with the number of nodes (see
+// MaxProjectionNodes).
+func (som *SOM) MDS() *Matrix {
+	// the distances are squared and centered in place
+	b := som.weightDistances()
+	n := len(b)
+
+	// double center the squared distances
+	means := make([]float64, n)
+	total := 0.0
+
+	for i := range b {
+		for j, d := range b[i] {
+			b[i][j] = d * d
+			means[i] += d * d
+		}
+
+		total += means[i]
+		means[i] /= float64(n)
+	}
+
+	total /= float64(n * n)
+
+	for i := range b {
+		for j := range b[i] {
+			b[i][j] = -0.5 * (b[i][j] - means[i] - means[j] + total)
+		}
+	}
+
+	coordinates := make([][]float64, n)
+
+	for i := range coordinates {
+		coordinates[i] = make([]float64, 2)
+	}
+
+	// find the two largest eigenvalues using power iteration with deflation
+	for k := 0; k < 2; k++ {
+		value, vector := powerIteration(b)
+
+		if value <= 0 {
+			break
+		}
+
+		scale := math.Sqrt(value)
+
+		for i, v := range vector {
+			coordinates[i][k] = v * scale
+
+			for j := range b[i] {
+				b[i][j] -= value * v * vector[j]
+			}
+		}
+	}
+
+	return NewMatrix(coordinates)
+}
+
+// Sammon projects the node weights to two dimensions using Sammon's nonlinear
+// mapping. The projection is initialized using MDS and then improved for the
+// specified number of iterations. It returns a matrix with the coordinates of
+// every node.
+//
+// Note: The memory grows quadratically with the number of nodes (see
+// MaxProjectionNodes).
+func (som *SOM) Sammon(iterations int) *Matrix {
+	distances := som.weightDistances()
+	projection := som.MDS()
+	y := projection.Data
+	n := len(y)
+
+	c := 0.0
+	for i := range distances {
+		for j := i + 1; j < n; j++ {
+			c += distances[i][j]
+		}
+	}
+
+	if c == 0 {
+		return projection
+	}
+
+	const magicFactor = 0.3
+	const epsilon = 1e-9
+
+	updates := make([][]float64, n)
+
+	for i := range updates {
+		updates[i] = make([]float64, 2)
+	}
+
+	for iteration := 0; iteration < iterations; iteration++ {
+		for p := range y {
+			for k := range updates[p] {
+				first := 0.0
+				second := 0.0
+
+				for j := range y {
+					if j == p {
+						continue
+					}
+
+					ds := math.Max(distances[p][j], epsilon)
+					d := math.Max(math.Hypot(y[p][0]-y[j][0], y[p][1]-y[j][1]), epsilon)
+					delta := y[p][k] - y[j][k]
+
+					first += (ds - d) / (d * ds) * delta
+					second += ((ds - d) - delta*delta/d*(1+(ds-d)/d)) / (ds * d)
+				}
+
+				first *= -2 / c
+				second *= -2 / c
+
+				updates[p][k] = 0
+				if second != 0 {
+					updates[p][k] = first / math.Abs(second)
+				}
+			}
+		}
+
+		for p := range y {
+			for k := range y[p] {
+				y[p][k] -= magicFactor * updates[p][k]
+			}
+		}
+	}
+
+	return NewMatrix(y)
+}
+
+// weightDistances returns the distances between all node weights.
+func (som *SOM) weightDistances() [][]float64 {
+	weights := som.WeightMatrix()
+	distances := make([][]float64, weights.Rows)
+
+	for i := range distances {
+		distances[i] = make([]float64, weights.Rows)
+	}
+
+	for i := range weights.Data {
+		for j := i + 1; j < weights.Rows; j++ {
+			d := som.D(weights.Data[i], weights.Data[j])
+			distances[i][j] = d
+			distances[j][i] = d
+		}
+	}
+
+	return distances
+}
+
+// sammonStress returns the error of the projection that is minimized by
+// Sammon's mapping.
+func (som *SOM) sammonStress(projection *Matrix) float64 {
+	distances := som.weightDistances()
+	y := projection.Data
+
+	c := 0.0
+	stress := 0.0
+
+	for i := range y {
+		for j := i + 1; j < len(y); j++ {
+			if distances[i][j] == 0 {
+				continue
+			}
+
+			d := math.Hypot(y[i][0]-y[j][0], y[i][1]-y[j][1])
+			c += distances[i][j]
+			stress += (distances[i][j] - d) * (distances[i][j] - d) / distances[i][j]
+		}
+	}
+
+	if c == 0 {
+		return 0
+	}
+
+	return stress / c
+}
+
+// powerIteration returns the dominant eigenvalue and eigenvector of the
+// symmetric matrix.
+func powerIteration(m [][]float64) (float64, []float64) {
+	n := len(m)
+	vector := make([]float64, n)
+	next := make([]float64, n)
+
+	// a non uniform start vector prevents starting orthogonal to the result
+	for i := range vector {
+		vector[i] = 1 / float64(i+1)
+	}
+
+	value := 0.0
+
+	for iteration := 0; iteration < 1000; iteration++ {
+		norm := 0.0
+
+		for i := range m {
+			next[i] = 0
+
+			for j, v := range vector {
+				next[i] += m[i][j] * v
+			}
+
+			norm += next[i] * next[i]
+		}
+
+		norm = math.Sqrt(norm)
+		if norm == 0 {
+			return 0, vector
+		}
+
+		change := 0.0
+
+		for i := range next {
+			next[i] /= norm
+			change += math.Abs(next[i] - vector[i])
+		}
+
+		vector, next = next, vector
+
+		if change < 1e-12 {
+			break
+		}
+	}
+
+	// the rayleigh quotient determines the sign of the eigenvalue
+	for i := range m {
+		for j, v := range vector {
+			value += vector[i] * m[i][j] * v
+		}
+	}
+
+	return value, vector
+}
@@ -0,0 +1,54 @@
+package gosom
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func projectedDistance(projection *Matrix, i, j int) float64 {
+	a, b := projection.Data[i], projection.Data[j]
+	return math.Hypot(a[0]-b[0], a[1]-b[1])
+}
+
+func TestMDS(t *testing.T) {
+	som := NewSOM(2, 2)
+	som.Nodes = NewLattice(2, 2, 3)
+	som.Nodes[1].Weights[0] = 3
+	som.Nodes[2].Weights[1] = 4
+	som.Nodes[3].Weights[0] = 3
+	som.Nodes[3].Weights[1] = 4
+
+	projection := som.MDS()
+	assert.Equal(t, 4, projection.Rows)
+	assert.Equal(t, 2, projection.Columns)
+	assert.InDelta(t, 3, projectedDistance(projection, 0, 1), 0.0001)
+	assert.InDelta(t, 4, projectedDistance(projection, 0, 2), 0.0001)
+	assert.InDelta(t, 5, projectedDistance(projection, 0, 3), 0.0001)
+}
+
+func TestSammon(t *testing.T) {
+	som := NewSOM(3, 3)
+	som.Nodes = NewLattice(3, 3, 3)
+
+	// bend the map in the third dimension
+	for _, node := range som.Nodes {
+		node.Weights[0] = float64(node.X())
+		node.Weights[1] = float64(node.Y())
+		node.Weights[2] = float64(node.X() * node.X())
+	}
+
+	mds := som.MDS()
+	sammon := som.Sammon(50)
+	assert.Equal(t, 9, sammon.Rows)
+	assert.True(t, som.sammonStress(sammon) < som.sammonStress(mds))
+}
+
+func TestSammonIdentical(t *testing.T) {
+	som := NewSOM(2, 2)
+	som.InitializeWithZeroes(2)
+
+	projection := som.Sammon(10)
+	assert.Equal(t, [][]float64{{0, 0}, {0, 0}, {0, 0}, {0, 0}}, projection.Data)
+}
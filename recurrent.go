@@ -0,0 +1,157 @@
+package gosom
+
+import "math"
+
+// A RecurrentSOM wraps a SOM and implements the recurrent self organizing map
+// for sequential data. Every node keeps a leaky integrated difference vector
+// between the consecutive inputs and its weights. The best matching node is
+// the one with the smallest difference vector in the euclidean norm,
+// independent of the distance function of the SOM.
+type RecurrentSOM struct {
+	SOM         *SOM
+	Leak        float64
+	Differences [][]float64
+}
+
+// NewRecurrentSOM returns a new RecurrentSOM using the leak factor [0..1].
+// A leak factor of one ignores all previous inputs and results in a regular
+// SOM.
+func NewRecurrentSOM(som *SOM, leak float64) *RecurrentSOM {
+	r := &RecurrentSOM{
+		SOM:  som,
+		Leak: leak,
+	}
+
+	r.Reset()
+
+	return r
+}
+
+// Reset clears the difference vectors. It should be called between
+// independent sequences.
+func (r *RecurrentSOM) Reset() {
+	r.Differences = make([][]float64, len(r.SOM.Nodes))
+
+	for i, node := range r.SOM.Nodes {
+		r.Differences[i] = make([]float64, len(node.Weights))
+	}
+}
+
+// Feed integrates the input into the difference vectors and returns the best
+// matching node. The first node is returned if all differences are NaN.
+//
+// Note: Dimensions that include NaNs keep their previous difference.
+func (r *RecurrentSOM) Feed(input []float64) *Node {
+	if len(r.Differences) != len(r.SOM.Nodes) {
+		r.Reset()
+	}
+
+	var winner *Node
+	t := math.Inf(1)
+
+	for i, node := range r.SOM.Nodes {
+		difference := r.Differences[i]
+		l := min(len(input), len(difference))
+
+		for j := 0; j < l; j++ {
+			if math.IsNaN(input[j]) {
+				continue
+			}
+
+			difference[j] = (1.0-r.Leak)*difference[j] + r.Leak*(input[j]-node.Weights[j])
+		}
+
+		d := squaredNorm(difference)
+
+		if d < t {
+			t = d
+			winner = node
+		}
+	}
+
+	if winner == nil && len(r.SOM.Nodes) > 0 {
+		winner = r.SOM.Nodes[0]
+	}
+
+	return winner
+}
+
+// Step feeds the input and applies one step of learning using the difference
+// vectors.
+func (r *RecurrentSOM) Step(input []float64, step int, training *Training) {
+	training.observe(step)
+
+	learningRate := training.LearningRate(step)
+	radius := training.Radius(step)
+	winningNode := r.Feed(input)
+
+	for i, node := range r.SOM.Nodes {
+		distance := r.SOM.LatticeDistance(winningNode, node)
+
+		if distance < radius*2 {
+			influence := r.SOM.NI(distance / radius)
+
+			for j, d := range r.Differences[i] {
+				node.Weights[j] += d * influence * learningRate
+			}
+		}
+	}
+
+	if step == training.Steps-1 {
+		training.observe(training.Steps)
+	}
+}
+
+// Train trains the SOM from the rows of data in order. Every pass over the
+// data is treated as one sequence and starts with cleared difference vectors.
+func (r *RecurrentSOM) Train(data *Matrix, training *Training) {
+	if data.Rows == 0 {
+		return
+	}
+
+	for step := 0; step < training.Steps; step++ {
+		if step%data.Rows == 0 {
+			r.Reset()
+		}
+
+		r.Step(data.Data[step%data.Rows], step, training)
+	}
+}
+
+// squaredNorm returns the squared euclidean norm of the vector.
+//
+// Note: Dimensions that include NaNs are ignored.
+func squaredNorm(vector []float64) float64 {
+	d := 0.0
+
+	for _, v := range vector {
+		if math.IsNaN(v) {
+			continue
+		}
+
+		d += v * v
+	}
+
+	return d
+}
+
+// Classify feeds the input and returns the classification.
+func (r *RecurrentSOM) Classify(input []float64) []float64 {
+	o := make([]float64, r.SOM.Dimensions())
+	copy(o, r.Feed(input).Weights)
+	return o
+}
+
+// ClassifySequence resets the difference vectors and returns the
+// classifications for the rows of data in order.
+func (r *RecurrentSOM) ClassifySequence(data *Matrix) *Matrix {
+	r.Reset()
+
+	values := make([][]float64, data.Rows)
+
+	for i, row := range data.Data {
+		values[i] = r.Classify(row)
+	}
+
+	return NewMatrix(values)
+}
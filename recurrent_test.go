@@ -0,0 +1,83 @@
+package gosom
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func recurrentSOM() *SOM {
+	som := NewSOM(2, 1)
+	som.Nodes = NewLattice(2, 1, 1)
+	som.Nodes[0].Weights[0] = 0.0
+	som.Nodes[1].Weights[0] = 1.0
+
+	return som
+}
+
+func TestRecurrentSOMFeed(t *testing.T) {
+	r := NewRecurrentSOM(recurrentSOM(), 0.5)
+
+	assert.Equal(t, r.SOM.Nodes[1], r.Feed([]float64{1.0}))
+	assert.Equal(t, [][]float64{{0.5}, {0.0}}, r.Differences)
+
+	// the history keeps the second node closer
+	assert.Equal(t, r.SOM.Nodes[1], r.Feed([]float64{0.4}))
+	assert.Equal(t, [][]float64{{0.45}, {-0.3}}, r.Differences)
+
+	r.Reset()
+	assert.Equal(t, r.SOM.Nodes[0], r.Feed([]float64{0.4}))
+}
+
+func TestRecurrentSOMWithoutLeak(t *testing.T) {
+	r := NewRecurrentSOM(recurrentSOM(), 1.0)
+
+	assert.Equal(t, []float64{1.0}, r.Classify([]float64{0.9}))
+	assert.Equal(t, []float64{0.0}, r.Classify([]float64{0.1}))
+}
+
+func TestRecurrentSOMClassifySequence(t *testing.T) {
+	r := NewRecurrentSOM(recurrentSOM(), 0.5)
+
+	m := r.ClassifySequence(NewMatrix([][]float64{{1.0}, {0.4}}))
+	assert.Equal(t, [][]float64{{1.0}, {1.0}}, m.Data)
+}
+
+func TestRecurrentSOMTrain(t *testing.T) {
+	som := recurrentSOM()
+	r := NewRecurrentSOM(som, 1.0)
+
+	data := NewMatrix([][]float64{{0.2}, {0.8}})
+	r.Train(data, NewTraining(som, 2, 0.5, 0.5, 0.5, 0.5))
+
+	assert.Equal(t, []float64{0.1}, som.Nodes[0].Weights)
+	assert.Equal(t, []float64{0.9}, som.Nodes[1].Weights)
+}
+
+func TestRecurrentSOMCosine(t *testing.T) {
+	som := recurrentSOM()
+	som.DistanceFunction = "cosine"
+	r := NewRecurrentSOM(som, 1.0)
+
+	assert.Equal(t, som.Nodes[1], r.Feed([]float64{0.9}))
+	assert.Equal(t, som.Nodes[0], r.Feed([]float64{0.1}))
+}
+
+func TestRecurrentSOMNaN(t *testing.T) {
+	som := recurrentSOM()
+	som.Nodes[0].Weights[0] = math.NaN()
+	som.Nodes[1].Weights[0] = math.NaN()
+	r := NewRecurrentSOM(som, 1.0)
+
+	assert.Equal(t, som.Nodes[0], r.Feed([]float64{0.5}))
+}
+
+func TestRecurrentSOMTrainEmpty(t *testing.T) {
+	som := recurrentSOM()
+	r := NewRecurrentSOM(som, 1.0)
+
+	assert.NotPanics(t, func() {
+		r.Train(&Matrix{}, NewTraining(som, 2, 0.5, 0.5, 0.5, 0.5))
+	})
+}
@@ -5,9 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"math/rand"
+	"sort"
+	"strconv"
+	"sync/atomic"
 
-	"github.com/256dpi/gosom/functions"
+	"github.com/gonum/floats"
 )
 
 // SOM holds an instance of a self organizing map.
@@ -18,6 +22,19 @@ type SOM struct {
 	CoolingFunction      string
 	DistanceFunction     string
 	NeighborhoodFunction string
+	Topology             string
+	Detector             *Detector `json:",omitempty"`
+
+	// Precision selects the floating point precision of the distance and
+	// update kernels of the online training. With a precision of 32 the
+	// training computes on a single precision copy of the weights and rounds
+	// the node weights to match it. It is a speedup of the training kernels
+	// only: the node weights stay in double precision and all other functions
+	// compute in double precision. Binary files store the weights with this
+	// precision. Zero or 64 select double precision.
+	Precision int `json:",omitempty"`
+
+	resolved atomic.Pointer[resolved]
 }
 
 // NewSOM creates and returns a new self organizing map.
@@ -28,6 +45,7 @@ func NewSOM(width, height int) *SOM {
 		CoolingFunction:      "linear",
 		DistanceFunction:     "euclidean",
 		NeighborhoodFunction: "cone",
+		Topology:             "rectangular",
 	}
 }
 
@@ -41,6 +59,10 @@ func LoadSOMFromJSON(source io.Reader) (*SOM, error) {
 		return nil, err
 	}
 
+	if som.Detector != nil {
+		som.Detector.SOM = som
+	}
+
 	return som, nil
 }
 
@@ -76,33 +98,21 @@ func (som *SOM) InitializeWithDataPoints(data *Matrix) {
 
 // Closest returns the closest Node to the input.
 func (som *SOM) Closest(input []float64) *Node {
-	var nodes []*Node
-
-	// select random to start with
-	node := som.Nodes[rand.Intn(len(som.Nodes))]
-	t := som.D(input, node.Weights)
-	nodes = append(nodes, node)
+	return som.closest(input, rand.Intn)
+}
 
-	for _, node := range som.Nodes {
-		// calculate distance
-		d := som.D(input, node.Weights)
-
-		if d < t {
-			// save distance, clear array and add winner
-			t = d
-			nodes = append([]*Node{}, node)
-		} else if d == t {
-			// add winner
-			nodes = append(nodes, node)
-		}
-	}
+// closest returns the closest Node to the input and breaks ties using the
+// random function.
+func (som *SOM) closest(input []float64, intn func(int) int) *Node {
+	kernel := som.functions().kernel
 
-	if len(nodes) > 1 {
-		// return random winner
-		return nodes[rand.Intn(len(nodes))]
-	}
+	// the node weights of a lattice are views into a contiguous buffer so that
+	// the scan walks through memory in order
+	i := bmu(len(som.Nodes), func(i int) float64 {
+		return kernel(input, som.Nodes[i].Weights)
+	}, intn)
 
-	return nodes[0]
+	return som.Nodes[i]
 }
 
 // Neighbors returns the K nearest neighbors to the input.
@@ -119,19 +129,41 @@ func (som *SOM) Neighbors(input []float64, K int) []*Node {
 
 // Step applies one step of learning.
 func (som *SOM) Step(data *Matrix, step int, training *Training) {
+	training.observe(step)
+
 	learningRate := training.LearningRate(step)
 	radius := training.Radius(step)
-	input := data.RandomRow()
-	winningNode := som.Closest(input)
+	input := data.Data[training.intn(data.Rows)]
+
+	// use the single precision weights if selected
+	weights := training.single(som, step)
+
+	var input32 []float32
+	var winningNode *Node
+	if weights != nil {
+		input32 = training.convert(input)
+		winningNode = som.closest32(input32, weights, training.intn)
+	} else {
+		winningNode = som.closest(input, training.intn)
+	}
 
-	for _, node := range som.Nodes {
-		distance := som.D(winningNode.Position, node.Position)
+	for i, node := range som.Nodes {
+		distance := som.LatticeDistance(winningNode, node)
 
 		if distance < radius*2 {
 			influence := som.NI(distance / radius)
-			node.Adjust(input, influence*learningRate)
+
+			if weights != nil {
+				adjust32(node, weights[i*len(node.Weights):(i+1)*len(node.Weights)], input32, influence*learningRate)
+			} else {
+				node.Adjust(input, influence*learningRate)
+			}
 		}
 	}
+
+	if step == training.Steps-1 {
+		training.observe(training.Steps)
+	}
 }
 
 // Train trains the SOM from the data.
@@ -199,6 +231,196 @@ func (som *SOM) WeightedInterpolate(input []float64, K int) []float64 {
 	return total
 }
 
+// Hits returns the number of rows in data that have a node as their closest
+// node for each node.
+func (som *SOM) Hits(data *Matrix) []int {
+	hits := make([]int, len(som.Nodes))
+
+	for _, row := range data.Data {
+		node := som.Closest(row)
+
+		// skip rows without a comparable node
+		if math.IsNaN(som.D(row, node.Weights)) {
+			continue
+		}
+
+		hits[node.Y()*som.Width+node.X()]++
+	}
+
+	return hits
+}
+
+// QuantizationError returns the average distance of the rows in data to their
+// closest node.
+func (som *SOM) QuantizationError(data *Matrix) float64 {
+	total := 0.0
+	rows := 0
+
+	for _, row := range data.Data {
+		d := som.D(row, som.Closest(row).Weights)
+
+		// skip rows without a comparable node
+		if math.IsNaN(d) {
+			continue
+		}
+
+		total += d
+		rows++
+	}
+
+	return total / float64(rows)
+}
+
+// TopographicError returns the share of rows in data whose closest and second
+// closest node are not adjacent on the lattice.
+func (som *SOM) TopographicError(data *Matrix) float64 {
+	errors := 0
+	rows := 0
+
+	for _, row := range data.Data {
+		first, second := -1, -1
+		d1, d2 := math.Inf(1), math.Inf(1)
+
+		for i, node := range som.Nodes {
+			d := som.D(row, node.Weights)
+
+			if d < d1 {
+				second, d2 = first, d1
+				first, d1 = i, d
+			} else if d < d2 {
+				second, d2 = i, d
+			}
+		}
+
+		// skip rows without a comparable node
+		if first < 0 {
+			continue
+		}
+
+		rows++
+
+		adjacent := false
+		for _, j := range som.adjacent(first) {
+			if j == second {
+				adjacent = true
+			}
+		}
+
+		if !adjacent {
+			errors++
+		}
+	}
+
+	return float64(errors) / float64(rows)
+}
+
+// ClassHits returns the distinct labels found in the specified column of data
+// and the hits of the rows with each label. The label column is ignored when
+// searching for the closest node.
+func (som *SOM) ClassHits(data *Matrix, column int) ([]float64, [][]int) {
+	var labels []float64
+	var hits [][]int
+
+	index := make(map[float64]int)
+
+	for _, label := range clearNANs(data.Column(column)) {
+		if _, ok := index[label]; !ok {
+			index[label] = len(labels)
+			labels = append(labels, label)
+		}
+	}
+
+	sort.Float64s(labels)
+
+	for i, label := range labels {
+		index[label] = i
+		hits = append(hits, make([]int, len(som.Nodes)))
+	}
+
+	input := make([]float64, data.Columns)
+
+	for _, row := range data.Data {
+		if math.IsNaN(row[column]) {
+			continue
+		}
+
+		copy(input, row)
+		input[column] = math.NaN()
+
+		node := som.Closest(input)
+		hits[index[row[column]]][node.Y()*som.Width+node.X()]++
+	}
+
+	return labels, hits
+}
+
+// NodeLabels assigns the label of every row in data to the closest node and
+// returns the distinct labels of every node ordered by their frequency. Rows
+// with an empty label are ignored.
+func (som *SOM) NodeLabels(data *Matrix, labels []string) [][]string {
+	return som.nodeLabels(data, labels, -1)
+}
+
+// NodeLabelsFromColumn works like NodeLabels but uses the values in the
+// specified column of data as labels. The label column is ignored when
+// searching for the closest node.
+func (som *SOM) NodeLabelsFromColumn(data *Matrix, column int) [][]string {
+	labels := make([]string, data.Rows)
+
+	for i, row := range data.Data {
+		if !math.IsNaN(row[column]) {
+			labels[i] = strconv.FormatFloat(row[column], 'g', -1, 64)
+		}
+	}
+
+	return som.nodeLabels(data, labels, column)
+}
+
+func (som *SOM) nodeLabels(data *Matrix, labels []string, column int) [][]string {
+	counts := make([]map[string]int, len(som.Nodes))
+	input := make([]float64, data.Columns)
+
+	for i, row := range data.Data {
+		if i >= len(labels) || labels[i] == "" {
+			continue
+		}
+
+		copy(input, row)
+		if column >= 0 {
+			input[column] = math.NaN()
+		}
+
+		node := som.Closest(input)
+		j := node.Y()*som.Width + node.X()
+
+		if counts[j] == nil {
+			counts[j] = make(map[string]int)
+		}
+
+		counts[j][labels[i]]++
+	}
+
+	nodeLabels := make([][]string, len(som.Nodes))
+
+	for i, count := range counts {
+		for label := range count {
+			nodeLabels[i] = append(nodeLabels[i], label)
+		}
+
+		sort.Slice(nodeLabels[i], func(a, b int) bool {
+			la, lb := nodeLabels[i][a], nodeLabels[i][b]
+
+			if count[la] != count[lb] {
+				return count[la] > count[lb]
+			}
+
+			return la < lb
+		})
+	}
+
+	return nodeLabels
+}
+
 // String returns a string matrix of all nodes and weights
 func (som *SOM) String() string {
 	s := ""
@@ -222,16 +444,128 @@ func (som *SOM) Dimensions() int {
 
 // WeightMatrix returns a matrix based on the weights of the nodes.
 func (som *SOM) WeightMatrix() *Matrix {
+	dimensions := som.Dimensions()
+	buffer := make([]float64, len(som.Nodes)*dimensions)
 	data := make([][]float64, len(som.Nodes))
 
 	for i, node := range som.Nodes {
-		data[i] = make([]float64, som.Dimensions())
+		data[i] = buffer[i*dimensions : (i+1)*dimensions : (i+1)*dimensions]
 		copy(data[i], node.Weights)
 	}
 
 	return NewMatrix(data)
 }
 
+// An Edge connects two adjacent nodes on the lattice.
+type Edge struct {
+	From     int
+	To       int
+	Distance float64
+}
+
+// UMatrix returns the average distance of every node to its adjacent nodes.
+func (som *SOM) UMatrix() []float64 {
+	values := make([]float64, len(som.Nodes))
+
+	for i, node := range som.Nodes {
+		var distances []float64
+
+		for _, j := range som.adjacent(i) {
+			distances = append(distances, som.D(node.Weights, som.Nodes[j].Weights))
+		}
+
+		values[i] = avg(distances)
+	}
+
+	return values
+}
+
+// UMatrixEdges returns the distances between all adjacent nodes. Every pair
+// of nodes is only returned once.
+func (som *SOM) UMatrixEdges() []Edge {
+	var edges []Edge
+
+	for i, node := range som.Nodes {
+		for _, j := range som.adjacent(i) {
+			if j < i {
+				continue
+			}
+
+			edges = append(edges, Edge{
+				From:     i,
+				To:       j,
+				Distance: som.D(node.Weights, som.Nodes[j].Weights),
+			})
+		}
+	}
+
+	return edges
+}
+
+// ParetoRadius returns the radius of a hypersphere around a data point that
+// contains about 20% of the data. It is estimated from the pairwise distances
+// of at most 1000 randomly selected rows.
+func (som *SOM) ParetoRadius(data *Matrix) float64 {
+	rows := data.Data
+
+	if len(rows) > 1000 {
+		rows = make([][]float64, 1000)
+
+		for i, j := range rand.Perm(data.Rows)[:1000] {
+			rows[i] = data.Data[j]
+		}
+	}
+
+	var distances []float64
+
+	for i := range rows {
+		for j := i + 1; j < len(rows); j++ {
+			distances = append(distances, som.D(rows[i], rows[j]))
+		}
+	}
+
+	if len(distances) == 0 {
+		return 0
+	}
+
+	return percentile(distances, 20)
+}
+
+// PMatrix returns the density of data at every node, which is the number of
+// rows that are within the radius of the node weights.
+func (som *SOM) PMatrix(data *Matrix, radius float64) []float64 {
+	values := make([]float64, len(som.Nodes))
+
+	for i, node := range som.Nodes {
+		for _, row := range data.Data {
+			if som.D(row, node.Weights) <= radius {
+				values[i]++
+			}
+		}
+	}
+
+	return values
+}
+
+// UStarMatrix returns the U-Matrix scaled by the density of data at every
+// node. Distances in dense regions are decreased while distances in sparse
+// regions are increased.
+func (som *SOM) UStarMatrix(data *Matrix, radius float64) []float64 {
+	u := som.UMatrix()
+	p := som.PMatrix(data, radius)
+
+	mean := avg(p)
+	max := floats.Max(p)
+
+	for i := range u {
+		if max > mean {
+			u[i] *= (p[i]-mean)/(mean-max) + 1
+		}
+	}
+
+	return u
+}
+
 // SaveAsJSON writes the SOM as a JSON file to destination.
 func (som *SOM) SaveAsJSON(destination io.Writer) error {
 	writer := json.NewEncoder(destination)
@@ -240,20 +574,72 @@ func (som *SOM) SaveAsJSON(destination io.Writer) error {
 
 // CF is a convenience function for calculating cooling factors.
 func (som *SOM) CF(progress float64) float64 {
-	return functions.CoolingFactor(som.CoolingFunction, progress)
+	return som.functions().cooling(progress)
 }
 
 // D is a convenience function for calculating distances.
 func (som *SOM) D(from, to []float64) float64 {
-	return functions.Distance(som.DistanceFunction, from, to)
+	return som.functions().distance(from, to)
 }
 
 // NI is a convenience function for calculating neighborhood influences.
 func (som *SOM) NI(distance float64) float64 {
-	return functions.NeighborhoodInfluence(som.NeighborhoodFunction, distance)
+	return som.functions().neighborhood(distance)
 }
 
 // N is a convenience function for accessing nodes.
 func (som *SOM) N(x, y int) *Node {
 	return som.Nodes[y*som.Width+x]
 }
+
+// Coordinates returns the coordinates of the node on the lattice. Odd rows of
+// a hexagonal lattice are shifted to the right by half a node and rows are
+// spaced so that all adjacent nodes have a distance of one.
+func (som *SOM) Coordinates(node *Node) []float64 {
+	if som.Topology != "hexagonal" {
+		return node.Position
+	}
+
+	return []float64{
+		node.Position[0] + 0.5*float64(node.Y()%2),
+		node.Position[1] * math.Sqrt(3) / 2,
+	}
+}
+
+// LatticeDistance returns the distance between two nodes on the lattice.
+func (som *SOM) LatticeDistance(from, to *Node) float64 {
+	return som.D(som.Coordinates(from), som.Coordinates(to))
+}
+
+// adjacent returns the indices of the nodes that are direct neighbors of the
+// node at index i on the lattice.
+func (som *SOM) adjacent(i int) []int {
+	var indices []int
+
+	x := i % som.Width
+	y := i / som.Width
+
+	add := func(x, y int) {
+		if x >= 0 && x < som.Width && y >= 0 && y < som.Height {
+			indices = append(indices, y*som.Width+x)
+		}
+	}
+
+	add(x-1, y)
+	add(x+1, y)
+
+	if som.Topology == "hexagonal" {
+		// odd rows are shifted to the right
+		o := y % 2
+
+		add(x-1+o, y-1)
+		add(x+o, y-1)
+		add(x-1+o, y+1)
+		add(x+o, y+1)
+	} else {
+		add(x, y-1)
+		add(x, y+1)
+	}
+
+	return indices
+}
@@ -2,6 +2,7 @@ package gosom
 
 import (
 	"math"
+	"math/rand"
 	"strings"
 	"testing"
 
@@ -101,3 +102,223 @@ func TestNeighborsWithNaNs(t *testing.T) {
 
 	assert.Equal(t, som.Neighbors([]float64{math.NaN(), 1.0}, 3), []*Node{som.Nodes[0], som.Nodes[1], som.Nodes[2]})
 }
+
+func TestHits(t *testing.T) {
+	som := NewSOM(2, 1)
+	som.Nodes = NewLattice(2, 1, 1)
+	som.Nodes[1].Weights[0] = 1.0
+
+	data := NewMatrix([][]float64{{0.1}, {0.9}, {1.0}})
+	assert.Equal(t, []int{1, 2}, som.Hits(data))
+}
+
+func TestQuantizationError(t *testing.T) {
+	som := NewSOM(2, 1)
+	som.InitializeWithZeroes(1)
+	som.Nodes[1].Weights[0] = 1
+
+	data := NewMatrix([][]float64{{0.25}, {1.5}})
+	assert.Equal(t, 0.375, som.QuantizationError(data))
+}
+
+func TestTopographicError(t *testing.T) {
+	som := NewSOM(3, 1)
+	som.InitializeWithZeroes(1)
+	som.Nodes[1].Weights[0] = 1
+	som.Nodes[2].Weights[0] = 0.5
+
+	// closest nodes 0 and 2 are not adjacent
+	data := NewMatrix([][]float64{{0.1}, {0.8}})
+	assert.Equal(t, 0.5, som.TopographicError(data))
+}
+
+func TestMetricsNaN(t *testing.T) {
+	som := NewSOM(3, 1)
+	som.InitializeWithZeroes(1)
+	som.Nodes[1].Weights[0] = 1
+	som.Nodes[2].Weights[0] = math.Inf(1)
+
+	// the first row has no comparable node
+	data := NewMatrix([][]float64{{math.Inf(1)}, {0.1}})
+	assert.Equal(t, 0.0, som.TopographicError(data))
+
+	som = NewSOM(2, 1)
+	som.InitializeWithZeroes(1)
+	som.Nodes[0].Weights[0] = math.Inf(1)
+	som.Nodes[1].Weights[0] = math.Inf(1)
+
+	data = NewMatrix([][]float64{{math.Inf(1)}, {1}})
+	assert.Equal(t, math.Inf(1), som.QuantizationError(data))
+
+	hits := som.Hits(data)
+	assert.Equal(t, 1, hits[0]+hits[1])
+}
+
+func TestClassHits(t *testing.T) {
+	som := NewSOM(2, 1)
+	som.Nodes = NewLattice(2, 1, 2)
+	som.Nodes[1].Weights[0] = 1.0
+	som.Nodes[1].Weights[1] = 1.0
+
+	data := NewMatrix([][]float64{{0.1, 2}, {0.9, 1}, {1.0, 2}, {0.8, math.NaN()}})
+	labels, hits := som.ClassHits(data, 1)
+	assert.Equal(t, []float64{1, 2}, labels)
+	assert.Equal(t, [][]int{{0, 1}, {1, 1}}, hits)
+}
+
+func TestNodeLabels(t *testing.T) {
+	som := NewSOM(2, 1)
+	som.Nodes = NewLattice(2, 1, 2)
+	som.Nodes[1].Weights[0] = 1.0
+	som.Nodes[1].Weights[1] = 1.0
+
+	data := NewMatrix([][]float64{{0.1, 0}, {0.9, 1}, {1.0, 0.8}, {0.8, 1}, {0, 0.1}})
+	labels := som.NodeLabels(data, []string{"a", "b", "c", "b", ""})
+	assert.Equal(t, [][]string{{"a"}, {"b", "c"}}, labels)
+}
+
+func TestNodeLabelsFromColumn(t *testing.T) {
+	som := NewSOM(2, 1)
+	som.Nodes = NewLattice(2, 1, 2)
+	som.Nodes[1].Weights[0] = 1.0
+	som.Nodes[1].Weights[1] = 1.0
+
+	data := NewMatrix([][]float64{{0.1, 2}, {0.9, 1}, {1.0, 2.5}, {0.8, math.NaN()}})
+	labels := som.NodeLabelsFromColumn(data, 1)
+	assert.Equal(t, [][]string{{"2"}, {"1", "2.5"}}, labels)
+}
+
+func TestAdjacent(t *testing.T) {
+	som := NewSOM(3, 3)
+
+	assert.Equal(t, []int{1, 3}, som.adjacent(0))
+	assert.Equal(t, []int{3, 5, 1, 7}, som.adjacent(4))
+
+	som.Topology = "hexagonal"
+	assert.Equal(t, []int{1, 3}, som.adjacent(0))
+	assert.Equal(t, []int{3, 5, 1, 2, 7, 8}, som.adjacent(4))
+	assert.Equal(t, []int{4, 0, 1, 6, 7}, som.adjacent(3))
+}
+
+func TestCoordinates(t *testing.T) {
+	som := NewSOM(3, 3)
+	som.Nodes = NewLattice(3, 3, 1)
+
+	assert.Equal(t, []float64{1, 1}, som.Coordinates(som.N(1, 1)))
+	assert.Equal(t, 1.0, som.LatticeDistance(som.N(1, 1), som.N(1, 0)))
+
+	som.Topology = "hexagonal"
+	assert.Equal(t, []float64{1.5, math.Sqrt(3) / 2}, som.Coordinates(som.N(1, 1)))
+	assert.InDelta(t, 1.0, som.LatticeDistance(som.N(1, 1), som.N(2, 0)), 0.0001)
+	assert.InDelta(t, 1.0, som.LatticeDistance(som.N(1, 1), som.N(1, 2)), 0.0001)
+}
+
+func TestUMatrix(t *testing.T) {
+	som := NewSOM(3, 1)
+	som.Nodes = NewLattice(3, 1, 1)
+	som.Nodes[1].Weights[0] = 1.0
+	som.Nodes[2].Weights[0] = 3.0
+
+	assert.Equal(t, []float64{1.0, 1.5, 2.0}, som.UMatrix())
+	assert.Equal(t, []Edge{
+		{From: 0, To: 1, Distance: 1.0},
+		{From: 1, To: 2, Distance: 2.0},
+	}, som.UMatrixEdges())
+}
+
+func TestParetoRadius(t *testing.T) {
+	som := NewSOM(1, 1)
+
+	data := NewMatrix([][]float64{{0}, {1}, {3}, {7}})
+	assert.Equal(t, 2.0, som.ParetoRadius(data))
+}
+
+func TestPMatrix(t *testing.T) {
+	som := NewSOM(3, 1)
+	som.Nodes = NewLattice(3, 1, 1)
+	som.Nodes[1].Weights[0] = 1.0
+	som.Nodes[2].Weights[0] = 3.0
+
+	data := NewMatrix([][]float64{{0.0}, {0.5}, {1.0}, {3.0}})
+	assert.Equal(t, []float64{2, 2, 1}, som.PMatrix(data, 0.5))
+}
+
+func TestUStarMatrix(t *testing.T) {
+	som := NewSOM(3, 1)
+	som.Nodes = NewLattice(3, 1, 1)
+	som.Nodes[1].Weights[0] = 1.0
+	som.Nodes[2].Weights[0] = 3.0
+
+	data := NewMatrix([][]float64{{0.0}, {0.5}, {1.0}, {3.0}})
+	assert.InDeltaSlice(t, []float64{0.0, 0.0, 6.0}, som.UStarMatrix(data, 0.5), 0.0001)
+}
+
+// scatter detaches the weights of all nodes from the contiguous buffer by
+// allocating them separately in random order.
+func scatter(l Lattice) {
+	for _, i := range rand.Perm(len(l)) {
+		weights := make([]float64, len(l[i].Weights))
+		copy(weights, l[i].Weights)
+		l[i].Weights = weights
+	}
+}
+
+func benchmarkSOM(contiguous bool) (*SOM, *Matrix) {
+	data := make([][]float64, 1000)
+	for i := range data {
+		data[i] = make([]float64, 128)
+		for j := range data[i] {
+			data[i][j] = rand.Float64()
+		}
+	}
+
+	m := NewMatrix(data)
+
+	som := NewSOM(50, 50)
+	som.InitializeWithRandomValues(m)
+
+	if !contiguous {
+		scatter(som.Nodes)
+	}
+
+	return som, m
+}
+
+func benchmarkClosest(b *testing.B, contiguous bool) {
+	som, data := benchmarkSOM(contiguous)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		som.Closest(data.Data[i%data.Rows])
+	}
+}
+
+// The scattered variants measure the per node storage that the contiguous
+// weight buffer replaces: go test -run - -bench 'Closest|Train'.
+func BenchmarkClosest(b *testing.B) {
+	benchmarkClosest(b, true)
+}
+
+func BenchmarkClosestScattered(b *testing.B) {
+	benchmarkClosest(b, false)
+}
+
+func benchmarkTrain(b *testing.B, contiguous bool) {
+	som, data := benchmarkSOM(contiguous)
+	training := NewTraining(som, 100, 0.5, 0.01, 25, 0.5)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		som.Train(data, training)
+	}
+}
+
+func BenchmarkTrain(b *testing.B) {
+	benchmarkTrain(b, true)
+}
+
+func BenchmarkTrainScattered(b *testing.B) {
+	benchmarkTrain(b, false)
+}
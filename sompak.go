@@ -0,0 +1,245 @@
+package gosom
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// LoadSOMFromCOD reads a codebook in the SOM_PAK format from source and
+// returns the SOM and the component names if present. The header line holds
+// the dimensions, topology (hexa or rect), width, height and neighborhood
+// function followed by one line of weights per node in row-major order.
+// Component names are read from a "#n" comment line as written by the SOM
+// Toolbox.
+func LoadSOMFromCOD(source io.Reader) (*SOM, []string, error) {
+	lines, names, err := readSOMPAK(source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(lines) == 0 {
+		return nil, nil, errors.New("gosom: missing codebook header")
+	}
+
+	header := lines[0]
+	if len(header) < 4 {
+		return nil, nil, errors.New("gosom: invalid codebook header")
+	}
+
+	dimensions, err1 := strconv.Atoi(header[0])
+	width, err2 := strconv.Atoi(header[2])
+	height, err3 := strconv.Atoi(header[3])
+	if err1 != nil || err2 != nil || err3 != nil || dimensions <= 0 || width <= 0 || height <= 0 {
+		return nil, nil, errors.New("gosom: invalid codebook header")
+	}
+
+	som := NewSOM(width, height)
+
+	switch header[1] {
+	case "rect":
+		som.Topology = "rectangular"
+	case "hexa":
+		som.Topology = "hexagonal"
+	default:
+		return nil, nil, fmt.Errorf("gosom: unsupported topology %q", header[1])
+	}
+
+	if len(header) > 4 {
+		switch header[4] {
+		case "bubble", "gaussian":
+			som.NeighborhoodFunction = header[4]
+		case "ep":
+			som.NeighborhoodFunction = "epanechicov"
+		default:
+			return nil, nil, fmt.Errorf("gosom: unsupported neighborhood %q", header[4])
+		}
+	}
+
+	// check the sizes against the lines before multiplying or allocating
+	nodes := len(lines) - 1
+	if width > nodes || height > nodes || width*height != nodes {
+		return nil, nil, fmt.Errorf("gosom: expected %dx%d nodes but got %d", width, height, nodes)
+	}
+
+	for _, line := range lines[1:] {
+		if len(line) < dimensions {
+			return nil, nil, fmt.Errorf("gosom: expected %d values but got %d", dimensions, len(line))
+		}
+	}
+
+	som.Nodes = NewLattice(width, height, dimensions)
+
+	for i, node := range som.Nodes {
+		err = parseSOMPAKValues(lines[i+1], node.Weights)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return som, names, nil
+}
+
+// SaveAsCOD writes the SOM as a codebook in the SOM_PAK format to destination.
+// The names are written as a "#n" comment line if present. Neighborhood
+// functions other than bubble and epanechicov are written as gaussian, as
+// they are not supported by SOM_PAK.
+func (som *SOM) SaveAsCOD(destination io.Writer, names []string) error {
+	topology := "rect"
+	if som.Topology == "hexagonal" {
+		topology = "hexa"
+	}
+
+	neighborhood := "gaussian"
+	switch som.NeighborhoodFunction {
+	case "bubble":
+		neighborhood = "bubble"
+	case "epanechicov":
+		neighborhood = "ep"
+	}
+
+	w := bufio.NewWriter(destination)
+
+	fmt.Fprintf(w, "%d %s %d %d %s\n", som.Dimensions(), topology, som.Width, som.Height, neighborhood)
+
+	if len(names) > 0 {
+		fmt.Fprintf(w, "#n %s\n", strings.Join(names, " "))
+	}
+
+	for _, node := range som.Nodes {
+		writeSOMPAKValues(w, node.Weights)
+	}
+
+	return w.Flush()
+}
+
+// LoadMatrixFromDAT reads data in the SOM_PAK format from source and returns
+// a new matrix. The first line holds the number of dimensions followed by one
+// line of values per row. Missing values are marked with "x" and mapped to
+// NaNs. Trailing labels are ignored.
+func LoadMatrixFromDAT(source io.Reader) (*Matrix, error) {
+	lines, _, err := readSOMPAK(source)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(lines) == 0 {
+		return nil, errors.New("gosom: missing data header")
+	}
+
+	dimensions, err := strconv.Atoi(lines[0][0])
+	if err != nil || dimensions <= 0 {
+		return nil, errors.New("gosom: invalid data header")
+	}
+
+	if len(lines) == 1 {
+		return nil, errors.New("gosom: no rows")
+	}
+
+	values := make([][]float64, len(lines)-1)
+
+	for i, line := range lines[1:] {
+		// check the values before allocating the row
+		if len(line) < dimensions {
+			return nil, fmt.Errorf("gosom: expected %d values but got %d", dimensions, len(line))
+		}
+
+		values[i] = make([]float64, dimensions)
+
+		err = parseSOMPAKValues(line, values[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return NewMatrix(values), nil
+}
+
+// SaveAsDAT writes the matrix in the SOM_PAK format to destination. NaNs are
+// written as missing values.
+func (m *Matrix) SaveAsDAT(destination io.Writer) error {
+	w := bufio.NewWriter(destination)
+
+	fmt.Fprintf(w, "%d\n", m.Columns)
+
+	for _, row := range m.Data {
+		writeSOMPAKValues(w, row)
+	}
+
+	return w.Flush()
+}
+
+// readSOMPAK returns the fields of all lines that are not empty or comments
+// and the names of a "#n" line.
+func readSOMPAK(source io.Reader) ([][]string, []string, error) {
+	var lines [][]string
+	var names []string
+
+	scanner := bufio.NewScanner(source)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		if fields[0] == "#n" {
+			names = fields[1:]
+			continue
+		}
+
+		if strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		lines = append(lines, fields)
+	}
+
+	return lines, names, scanner.Err()
+}
+
+// parseSOMPAKValues parses the fields into values and ignores trailing fields.
+func parseSOMPAKValues(fields []string, values []float64) error {
+	if len(fields) < len(values) {
+		return fmt.Errorf("gosom: expected %d values but got %d", len(values), len(fields))
+	}
+
+	for i := range values {
+		if fields[i] == "x" {
+			values[i] = math.NaN()
+			continue
+		}
+
+		f, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return err
+		}
+
+		values[i] = f
+	}
+
+	return nil
+}
+
+// writeSOMPAKValues writes the values as a line and NaNs as missing values.
+func writeSOMPAKValues(w *bufio.Writer, values []float64) {
+	for i, value := range values {
+		if i > 0 {
+			w.WriteByte(' ')
+		}
+
+		if math.IsNaN(value) {
+			w.WriteByte('x')
+		} else {
+			w.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+		}
+	}
+
+	w.WriteByte('\n')
+}
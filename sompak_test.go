@@ -0,0 +1,106 @@
+package gosom
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSOMFromCOD(t *testing.T) {
+	cod := `2 hexa 2 2 bubble
+# comment
+#n foo bar
+0 0.5
+1 1.5 label
+2 2.5
+
+3 3.5
+`
+
+	som, names, err := LoadSOMFromCOD(strings.NewReader(cod))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo", "bar"}, names)
+	assert.Equal(t, "hexagonal", som.Topology)
+	assert.Equal(t, "bubble", som.NeighborhoodFunction)
+	assert.Equal(t, 2, som.Dimensions())
+	assert.Equal(t, []float64{2, 2.5}, som.N(0, 1).Weights)
+	assert.Equal(t, []float64{3, 3.5}, som.N(1, 1).Weights)
+}
+
+func TestLoadSOMFromCODErrors(t *testing.T) {
+	_, _, err := LoadSOMFromCOD(strings.NewReader(""))
+	assert.Error(t, err)
+
+	_, _, err = LoadSOMFromCOD(strings.NewReader("2 cube 1 1 bubble\n0 0\n"))
+	assert.Error(t, err)
+
+	_, _, err = LoadSOMFromCOD(strings.NewReader("2 rect 1 2 bubble\n0 0\n"))
+	assert.Error(t, err)
+
+	_, _, err = LoadSOMFromCOD(strings.NewReader("2 rect 1 1 bubble\n0\n"))
+	assert.Error(t, err)
+
+	_, _, err = LoadSOMFromCOD(strings.NewReader("-2 rect 1 1 bubble\n0 0\n"))
+	assert.Error(t, err)
+
+	_, _, err = LoadSOMFromCOD(strings.NewReader("2 rect -1 -1 bubble\n0 0\n"))
+	assert.Error(t, err)
+
+	_, _, err = LoadSOMFromCOD(strings.NewReader("2 rect 0 0 bubble\n"))
+	assert.Error(t, err)
+
+	_, _, err = LoadSOMFromCOD(strings.NewReader("1000000000000 rect 1 1 bubble\n0 0\n"))
+	assert.Error(t, err)
+
+	_, _, err = LoadSOMFromCOD(strings.NewReader("2 rect 4294967296 4294967296 bubble\n"))
+	assert.Error(t, err)
+}
+
+func TestSaveAsCOD(t *testing.T) {
+	som := NewSOM(2, 1)
+	som.InitializeWithZeroes(2)
+	som.Nodes[1].Weights[0] = 0.25
+
+	var buf bytes.Buffer
+	require.NoError(t, som.SaveAsCOD(&buf, []string{"a", "b"}))
+	assert.Equal(t, "2 rect 2 1 gaussian\n#n a b\n0 0\n0.25 0\n", buf.String())
+
+	loaded, names, err := LoadSOMFromCOD(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, names)
+	assert.Equal(t, som.Nodes, loaded.Nodes)
+	assert.Equal(t, "rectangular", loaded.Topology)
+}
+
+func TestDAT(t *testing.T) {
+	dat := "3\n1 2 3 label\n4 x 6\n"
+
+	m, err := LoadMatrixFromDAT(strings.NewReader(dat))
+	require.NoError(t, err)
+	assert.Equal(t, 2, m.Rows)
+	assert.Equal(t, 3, m.Columns)
+	assert.True(t, m.NaNs)
+	assert.True(t, math.IsNaN(m.Data[1][1]))
+
+	var buf bytes.Buffer
+	require.NoError(t, m.SaveAsDAT(&buf))
+	assert.Equal(t, "3\n1 2 3\n4 x 6\n", buf.String())
+}
+
+func TestLoadMatrixFromDATErrors(t *testing.T) {
+	_, err := LoadMatrixFromDAT(strings.NewReader(""))
+	assert.Error(t, err)
+
+	_, err = LoadMatrixFromDAT(strings.NewReader("3\n"))
+	assert.Error(t, err)
+
+	_, err = LoadMatrixFromDAT(strings.NewReader("-3\n1 2 3\n"))
+	assert.Error(t, err)
+
+	_, err = LoadMatrixFromDAT(strings.NewReader("1000000000000\n1 2 3\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,35 @@
+package gosom
+
+// A Source is a serializable random number source based on SplitMix64. It can
+// be assigned to a Training to make it reproducible and resumable.
+type Source struct {
+	State uint64
+}
+
+// NewSource returns a new Source initialized with the seed.
+func NewSource(seed int64) *Source {
+	s := &Source{}
+	s.Seed(seed)
+	return s
+}
+
+// Seed resets the source to the state of the seed.
+func (s *Source) Seed(seed int64) {
+	s.State = uint64(seed)
+}
+
+// Uint64 returns the next pseudo random number.
+func (s *Source) Uint64() uint64 {
+	s.State += 0x9e3779b97f4a7c15
+
+	z := s.State
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+
+	return z ^ (z >> 31)
+}
+
+// Int63 returns the next non-negative pseudo random number.
+func (s *Source) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
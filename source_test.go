@@ -0,0 +1,23 @@
+package gosom
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSource(t *testing.T) {
+	s1 := NewSource(1)
+	s2 := NewSource(1)
+
+	assert.Equal(t, s1.Uint64(), s2.Uint64())
+	assert.Equal(t, s1.State, s2.State)
+
+	copied := &Source{State: s1.State}
+	assert.Equal(t, rand.New(s1).Intn(100), rand.New(copied).Intn(100))
+
+	for i := 0; i < 100; i++ {
+		assert.True(t, s1.Int63() >= 0)
+	}
+}
@@ -0,0 +1,396 @@
+package gosom
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A SparseVector holds the indices and values of the non-zero dimensions of a
+// vector.
+type SparseVector struct {
+	Indices []int
+	Values  []float64
+}
+
+// NewSparseVector returns a sparse vector with the non-zero values of the
+// dense vector.
+func NewSparseVector(dense []float64) SparseVector {
+	var v SparseVector
+
+	for i, value := range dense {
+		if value != 0 {
+			v.Indices = append(v.Indices, i)
+			v.Values = append(v.Values, value)
+		}
+	}
+
+	return v
+}
+
+// Len implements the sort.Interface.
+func (v SparseVector) Len() int {
+	return len(v.Indices)
+}
+
+// Less implements the sort.Interface.
+func (v SparseVector) Less(i, j int) bool {
+	return v.Indices[i] < v.Indices[j]
+}
+
+// Swap implements the sort.Interface.
+func (v SparseVector) Swap(i, j int) {
+	v.Indices[i], v.Indices[j] = v.Indices[j], v.Indices[i]
+	v.Values[i], v.Values[j] = v.Values[j], v.Values[i]
+}
+
+// Dense returns the vector as a dense vector with the specified dimensions.
+func (v SparseVector) Dense(dimensions int) []float64 {
+	dense := make([]float64, dimensions)
+
+	for i, index := range v.Indices {
+		if index < dimensions {
+			dense[index] = v.Values[i]
+		}
+	}
+
+	return dense
+}
+
+// Dot returns the dot product with the dense vector.
+func (v SparseVector) Dot(dense []float64) float64 {
+	dot := 0.0
+
+	for i, index := range v.Indices {
+		if index < len(dense) {
+			dot += v.Values[i] * dense[index]
+		}
+	}
+
+	return dot
+}
+
+// SquaredNorm returns the squared euclidean norm of the vector.
+func (v SparseVector) SquaredNorm() float64 {
+	norm := 0.0
+
+	for _, value := range v.Values {
+		norm += value * value
+	}
+
+	return norm
+}
+
+// A SparseMatrix holds rows of sparse vectors.
+type SparseMatrix struct {
+	Data    []SparseVector
+	Rows    int
+	Columns int
+}
+
+// NewSparseMatrix returns a new sparse matrix. The columns are extended to
+// cover the highest index of all rows.
+func NewSparseMatrix(data []SparseVector, columns int) *SparseMatrix {
+	for _, row := range data {
+		for _, index := range row.Indices {
+			columns = max(columns, index+1)
+		}
+	}
+
+	return &SparseMatrix{
+		Data:    data,
+		Rows:    len(data),
+		Columns: columns,
+	}
+}
+
+// Dense returns the sparse matrix as a dense matrix.
+func (m *SparseMatrix) Dense() *Matrix {
+	data := make([][]float64, m.Rows)
+
+	for i, row := range m.Data {
+		data[i] = row.Dense(m.Columns)
+	}
+
+	return NewMatrix(data)
+}
+
+// LoadSparseMatrixFromLIBSVM reads data in the LIBSVM or SVMlight format from
+// source and returns a sparse matrix and the labels. Every line holds a label
+// followed by index:value pairs with indices starting at one. Comments and
+// query ids are ignored and the pairs of every row are sorted by index.
+func LoadSparseMatrixFromLIBSVM(source io.Reader) (*SparseMatrix, []float64, error) {
+	var rows []SparseVector
+	var labels []float64
+
+	scanner := bufio.NewScanner(source)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		label, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var row SparseVector
+
+		for _, field := range fields[1:] {
+			pair := strings.SplitN(field, ":", 2)
+			if len(pair) != 2 {
+				return nil, nil, fmt.Errorf("gosom: invalid pair %q", field)
+			}
+
+			if pair[0] == "qid" {
+				continue
+			}
+
+			index, err := strconv.Atoi(pair[0])
+			if err != nil || index < 1 {
+				return nil, nil, fmt.Errorf("gosom: invalid index %q", pair[0])
+			}
+
+			value, err := strconv.ParseFloat(pair[1], 64)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if value != 0 {
+				row.Indices = append(row.Indices, index-1)
+				row.Values = append(row.Values, value)
+			}
+		}
+
+		sort.Sort(row)
+
+		rows = append(rows, row)
+		labels = append(labels, label)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return NewSparseMatrix(rows, 0), labels, nil
+}
+
+// InitializeWithSparseDataPoints initializes the nodes with random data points.
+func (som *SOM) InitializeWithSparseDataPoints(data *SparseMatrix) {
+	som.Nodes = NewLattice(som.Width, som.Height, data.Columns)
+
+	for _, node := range som.Nodes {
+		copy(node.Weights, data.Data[rand.Intn(data.Rows)].Dense(data.Columns))
+	}
+}
+
+// A SparseSOM trains and queries a SOM using sparse inputs. The weights of
+// every node are stored as a vector and a scale factor and the squared norms
+// of the weights are kept up to date. This allows searching the closest node
+// and adjusting the nodes by only touching the non-zero dimensions of the
+// input when using the euclidean or cosine distance. Other distance functions
+// fall back to dense computations.
+//
+// Note: While training the node weights are not scaled. Flush must be called
+// before using the SOM directly.
+type SparseSOM struct {
+	SOM *SOM
+
+	scales []float64
+	norms  []float64
+	dots   []float64
+}
+
+// NewSparseSOM returns a new SparseSOM for the SOM.
+func NewSparseSOM(som *SOM) *SparseSOM {
+	s := &SparseSOM{
+		SOM:    som,
+		scales: make([]float64, len(som.Nodes)),
+		norms:  make([]float64, len(som.Nodes)),
+		dots:   make([]float64, len(som.Nodes)),
+	}
+
+	for i := range som.Nodes {
+		s.scales[i] = 1
+		s.norm(i)
+	}
+
+	return s
+}
+
+// Flush applies the scale factors to the node weights and recalculates the
+// norms.
+func (s *SparseSOM) Flush() {
+	for i, node := range s.SOM.Nodes {
+		if s.scales[i] != 1 {
+			for j := range node.Weights {
+				node.Weights[j] *= s.scales[i]
+			}
+
+			s.scales[i] = 1
+		}
+
+		s.norm(i)
+	}
+}
+
+// Closest returns the closest node to the input.
+func (s *SparseSOM) Closest(input SparseVector) *Node {
+	return s.closest(input, rand.Intn)
+}
+
+// Step applies one step of learning using a random row of data.
+func (s *SparseSOM) Step(data *SparseMatrix, step int, training *Training) {
+	s.observe(step, training)
+
+	learningRate := training.LearningRate(step)
+	radius := training.Radius(step)
+	input := data.Data[training.intn(data.Rows)]
+	winningNode := s.closest(input, training.intn)
+	inputNorm := input.SquaredNorm()
+
+	for i, node := range s.SOM.Nodes {
+		distance := s.SOM.LatticeDistance(winningNode, node)
+
+		if distance < radius*2 {
+			influence := s.SOM.NI(distance / radius)
+			s.adjust(i, input, inputNorm, influence*learningRate)
+		}
+	}
+
+	if step == training.Steps-1 {
+		s.observe(training.Steps, training)
+	}
+}
+
+// Train trains the SOM from the sparse data and flushes the weights.
+func (s *SparseSOM) Train(data *SparseMatrix, training *Training) {
+	for step := 0; step < training.Steps; step++ {
+		s.Step(data, step, training)
+	}
+
+	s.Flush()
+}
+
+// observe flushes the weights before the training takes a snapshot.
+func (s *SparseSOM) observe(step int, training *Training) {
+	if training.Animation != nil {
+		s.Flush()
+	}
+
+	training.observe(step)
+}
+
+// closest calculates the dot products of all nodes with the input and returns
+// the closest node. Ties are broken using the random function like in the
+// dense search.
+func (s *SparseSOM) closest(input SparseVector, intn func(int) int) *Node {
+	inputNorm := input.SquaredNorm()
+
+	var dense []float64
+	if s.SOM.DistanceFunction != "euclidean" && s.SOM.DistanceFunction != "cosine" {
+		dense = input.Dense(s.SOM.Dimensions())
+	}
+
+	for i, node := range s.SOM.Nodes {
+		s.dots[i] = s.scales[i] * input.Dot(node.Weights)
+	}
+
+	i := bmu(len(s.SOM.Nodes), func(i int) float64 {
+		return s.distance(i, inputNorm, dense)
+	}, intn)
+
+	return s.SOM.Nodes[i]
+}
+
+// distance returns the distance of node i to the input using the dot product
+// of the current search.
+func (s *SparseSOM) distance(i int, inputNorm float64, dense []float64) float64 {
+	switch s.SOM.DistanceFunction {
+	case "euclidean":
+		return math.Sqrt(math.Max(0, s.norms[i]-2*s.dots[i]+inputNorm))
+	case "cosine":
+		if s.norms[i] == 0 || inputNorm == 0 {
+			return 1
+		}
+
+		return 1 - s.dots[i]/(math.Sqrt(s.norms[i])*math.Sqrt(inputNorm))
+	}
+
+	weights := s.SOM.Nodes[i].Weights
+	if s.scales[i] != 1 {
+		weights = make([]float64, len(weights))
+		for j, w := range s.SOM.Nodes[i].Weights {
+			weights[j] = w * s.scales[i]
+		}
+	}
+
+	return s.SOM.D(dense, weights)
+}
+
+// adjust moves the weights of node i towards the input using the dot product
+// of the last search.
+func (s *SparseSOM) adjust(i int, input SparseVector, inputNorm, influence float64) {
+	weights := s.SOM.Nodes[i].Weights
+
+	// replace the weights if the node fully adopts the input
+	if influence >= 1 {
+		for j := range weights {
+			weights[j] = 0
+		}
+
+		for j, index := range input.Indices {
+			weights[index] = input.Values[j]
+		}
+
+		s.scales[i] = 1
+		s.norms[i] = inputNorm
+
+		return
+	}
+
+	// apply the scale before it gets too small
+	scale := s.scales[i] * (1 - influence)
+	if scale < 1e-9 {
+		for j := range weights {
+			weights[j] *= s.scales[i]
+		}
+
+		s.scales[i] = 1
+		scale = 1 - influence
+	}
+
+	// w' = (1-h) * w + h * x
+	for j, index := range input.Indices {
+		weights[index] += influence * input.Values[j] / scale
+	}
+
+	s.scales[i] = scale
+	s.norms[i] = (1-influence)*(1-influence)*s.norms[i] +
+		2*influence*(1-influence)*s.dots[i] + influence*influence*inputNorm
+}
+
+// norm recalculates the squared norm of node i.
+func (s *SparseSOM) norm(i int) {
+	norm := 0.0
+
+	for _, w := range s.SOM.Nodes[i].Weights {
+		norm += w * w
+	}
+
+	s.norms[i] = norm * s.scales[i] * s.scales[i]
+}
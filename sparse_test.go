@@ -0,0 +1,141 @@
+package gosom
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSparseVector(t *testing.T) {
+	v := NewSparseVector([]float64{0, 2, 0, 3})
+	assert.Equal(t, []int{1, 3}, v.Indices)
+	assert.Equal(t, []float64{2, 3}, v.Values)
+	assert.Equal(t, []float64{0, 2, 0, 3, 0}, v.Dense(5))
+	assert.Equal(t, 2.0+6.0, v.Dot([]float64{5, 1, 7, 2}))
+	assert.Equal(t, 13.0, v.SquaredNorm())
+}
+
+func TestLoadSparseMatrixFromLIBSVM(t *testing.T) {
+	source := `# comment
++1 3:2 1:0.5
+-1 qid:4 2:1 # trailing comment
+
+0
+`
+
+	m, labels, err := LoadSparseMatrixFromLIBSVM(strings.NewReader(source))
+	require.NoError(t, err)
+	assert.Equal(t, 3, m.Rows)
+	assert.Equal(t, 3, m.Columns)
+	assert.Equal(t, []float64{1, -1, 0}, labels)
+	assert.Equal(t, [][]float64{{0.5, 0, 2}, {0, 1, 0}, {0, 0, 0}}, m.Dense().Data)
+	assert.Equal(t, []int{0, 2}, m.Data[0].Indices)
+	assert.Equal(t, SparseFingerprint(m), SparseFingerprint(NewSparseMatrix([]SparseVector{
+		{Indices: []int{0, 1, 2}, Values: []float64{0.5, 0, 2}},
+		{Indices: []int{1}, Values: []float64{1}},
+		{},
+	}, 3)))
+	assert.NotEqual(t, SparseFingerprint(m), SparseFingerprint(NewSparseMatrix([]SparseVector{
+		{Indices: []int{0, 2}, Values: []float64{0.5, 2}},
+		{},
+		{Indices: []int{1}, Values: []float64{1}},
+	}, 3)))
+
+	_, _, err = LoadSparseMatrixFromLIBSVM(strings.NewReader("1 0:1\n"))
+	assert.Error(t, err)
+
+	_, _, err = LoadSparseMatrixFromLIBSVM(strings.NewReader("1 2\n"))
+	assert.Error(t, err)
+}
+
+func sparseTestData(rows, columns int) *SparseMatrix {
+	r := rand.New(rand.NewSource(1))
+	data := make([]SparseVector, rows)
+
+	for i := range data {
+		dense := make([]float64, columns)
+		for j := range dense {
+			if r.Float64() < 0.2 {
+				dense[j] = r.Float64()
+			}
+		}
+
+		data[i] = NewSparseVector(dense)
+	}
+
+	return NewSparseMatrix(data, columns)
+}
+
+func TestSparseSOMClosest(t *testing.T) {
+	data := sparseTestData(20, 30)
+
+	for _, distance := range []string{"euclidean", "cosine", "manhattan"} {
+		som := NewSOM(4, 4)
+		som.DistanceFunction = distance
+		som.InitializeWithSparseDataPoints(data)
+
+		s := NewSparseSOM(som)
+
+		for _, row := range data.Data {
+			assert.Equal(t, som.D(row.Dense(data.Columns), som.Closest(row.Dense(data.Columns)).Weights),
+				som.D(row.Dense(data.Columns), s.Closest(row).Weights), distance)
+		}
+	}
+}
+
+func TestSparseSOMTrain(t *testing.T) {
+	data := sparseTestData(50, 30)
+
+	som1 := NewSOM(5, 5)
+	som1.InitializeWithZeroes(data.Columns)
+
+	for i, node := range som1.Nodes {
+		copy(node.Weights, data.Data[i%data.Rows].Dense(data.Columns))
+	}
+
+	som2 := NewSOM(5, 5)
+	som2.InitializeWithZeroes(data.Columns)
+
+	for i, node := range som2.Nodes {
+		copy(node.Weights, som1.Nodes[i].Weights)
+	}
+
+	training1 := NewTraining(som1, 500, 0.5, 0.01, 3, 0.5)
+	training1.Source = NewSource(1)
+	som1.Train(data.Dense(), training1)
+
+	training2 := NewTraining(som2, 500, 0.5, 0.01, 3, 0.5)
+	training2.Source = NewSource(1)
+	NewSparseSOM(som2).Train(data, training2)
+
+	for i, node := range som1.Nodes {
+		assert.InDeltaSlice(t, node.Weights, som2.Nodes[i].Weights, 1e-9)
+	}
+}
+
+func TestSparseSOMFlush(t *testing.T) {
+	som := NewSOM(1, 1)
+	som.InitializeWithZeroes(3)
+	copy(som.Nodes[0].Weights, []float64{1, 2, 3})
+
+	s := NewSparseSOM(som)
+	assert.Equal(t, 14.0, s.norms[0])
+
+	input := NewSparseVector([]float64{0, 4, 0})
+	s.Closest(input)
+	s.adjust(0, input, input.SquaredNorm(), 0.5)
+	assert.Equal(t, 0.5, s.scales[0])
+	assert.InDelta(t, 0.25+9+2.25, s.norms[0], 1e-12)
+
+	s.Flush()
+	assert.Equal(t, 1.0, s.scales[0])
+	assert.InDeltaSlice(t, []float64{0.5, 3, 1.5}, som.Nodes[0].Weights, 1e-12)
+	assert.InDelta(t, 0.25+9+2.25, s.norms[0], 1e-12)
+
+	s.adjust(0, input, input.SquaredNorm(), 1)
+	assert.Equal(t, []float64{0, 4, 0}, som.Nodes[0].Weights)
+	assert.Equal(t, 16.0, s.norms[0])
+}
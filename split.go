@@ -0,0 +1,154 @@
+package gosom
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// A Fold is a pair of training and test data of a k-fold cross-validation.
+type Fold struct {
+	Train *Matrix
+	Test  *Matrix
+}
+
+// Split shuffles the rows and returns a training and a test matrix. The ratio
+// is the share of rows that are used for testing. The global random number
+// generator is used if random is nil.
+//
+// Note: The returned matrices share the rows with the original matrix.
+func (m *Matrix) Split(ratio float64, random *rand.Rand) (*Matrix, *Matrix, error) {
+	return m.split([][]int{shuffle(m.Rows, random)}, ratio)
+}
+
+// StratifiedSplit shuffles the rows and returns a training and a test matrix
+// that preserve the share of every class in the specified column. The ratio is
+// the share of rows of every class that are used for testing.
+func (m *Matrix) StratifiedSplit(column int, ratio float64, random *rand.Rand) (*Matrix, *Matrix, error) {
+	return m.split(m.classes(column, random), ratio)
+}
+
+// KFold shuffles the rows and returns k folds. Every row is used once for
+// testing.
+func (m *Matrix) KFold(k int, random *rand.Rand) ([]Fold, error) {
+	return m.folds([][]int{shuffle(m.Rows, random)}, k)
+}
+
+// StratifiedKFold shuffles the rows and returns k folds that preserve the
+// share of every class in the specified column.
+func (m *Matrix) StratifiedKFold(column, k int, random *rand.Rand) ([]Fold, error) {
+	return m.folds(m.classes(column, random), k)
+}
+
+// split splits every group of row indexes according to the ratio.
+func (m *Matrix) split(groups [][]int, ratio float64) (*Matrix, *Matrix, error) {
+	if ratio <= 0 || ratio >= 1 {
+		return nil, nil, errors.New("gosom: ratio must be between zero and one")
+	}
+
+	var train, test []int
+
+	for _, group := range groups {
+		n := int(math.Round(float64(len(group)) * ratio))
+		test = append(test, group[:n]...)
+		train = append(train, group[n:]...)
+	}
+
+	if len(train) == 0 || len(test) == 0 {
+		return nil, nil, errors.New("gosom: not enough rows to split")
+	}
+
+	return m.subset(train), m.subset(test), nil
+}
+
+// folds assigns the row indexes of the concatenated groups to the folds in
+// turns.
+func (m *Matrix) folds(groups [][]int, k int) ([]Fold, error) {
+	if k < 2 {
+		return nil, errors.New("gosom: at least two folds are required")
+	}
+
+	if m.Rows < k {
+		return nil, errors.New("gosom: not enough rows for the folds")
+	}
+
+	assignments := make([][]int, k)
+	i := 0
+
+	for _, group := range groups {
+		for _, row := range group {
+			assignments[i%k] = append(assignments[i%k], row)
+			i++
+		}
+	}
+
+	folds := make([]Fold, k)
+
+	for j := range folds {
+		var train []int
+		for l, assignment := range assignments {
+			if l != j {
+				train = append(train, assignment...)
+			}
+		}
+
+		folds[j] = Fold{
+			Train: m.subset(train),
+			Test:  m.subset(assignments[j]),
+		}
+	}
+
+	return folds, nil
+}
+
+// classes returns the shuffled row indexes grouped by the values in the
+// column. The groups are ordered by value.
+func (m *Matrix) classes(column int, random *rand.Rand) [][]int {
+	groups := map[float64][]int{}
+	var values []float64
+
+	for _, i := range shuffle(m.Rows, random) {
+		value := m.Data[i][column]
+
+		// group missing values together
+		if math.IsNaN(value) {
+			value = math.Inf(1)
+		}
+
+		if _, ok := groups[value]; !ok {
+			values = append(values, value)
+		}
+
+		groups[value] = append(groups[value], i)
+	}
+
+	sort.Float64s(values)
+
+	list := make([][]int, len(values))
+	for i, value := range values {
+		list[i] = groups[value]
+	}
+
+	return list
+}
+
+// subset returns a matrix with the specified rows.
+func (m *Matrix) subset(rows []int) *Matrix {
+	data := make([][]float64, len(rows))
+
+	for i, row := range rows {
+		data[i] = m.Data[row]
+	}
+
+	return NewMatrix(data)
+}
+
+// shuffle returns a random permutation of n indexes.
+func shuffle(n int, random *rand.Rand) []int {
+	if random == nil {
+		return rand.Perm(n)
+	}
+
+	return random.Perm(n)
+}
@@ -0,0 +1,123 @@
+package gosom
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func splitTestData() *Matrix {
+	data := make([][]float64, 20)
+
+	for i := range data {
+		// 15 rows of class 0 and 5 rows of class 1
+		class := 0.0
+		if i >= 15 {
+			class = 1
+		}
+
+		data[i] = []float64{float64(i), class}
+	}
+
+	return NewMatrix(data)
+}
+
+func splitIDs(matrices ...*Matrix) []int {
+	var ids []int
+
+	for _, m := range matrices {
+		for _, row := range m.Data {
+			ids = append(ids, int(row[0]))
+		}
+	}
+
+	sort.Ints(ids)
+
+	return ids
+}
+
+func countClass(m *Matrix, class float64) int {
+	n := 0
+
+	for _, row := range m.Data {
+		if row[1] == class {
+			n++
+		}
+	}
+
+	return n
+}
+
+func TestSplit(t *testing.T) {
+	m := splitTestData()
+
+	train, test, err := m.Split(0.25, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+	assert.Equal(t, 15, train.Rows)
+	assert.Equal(t, 5, test.Rows)
+	assert.Equal(t, splitIDs(m), splitIDs(train, test))
+
+	train2, test2, err := m.Split(0.25, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+	assert.Equal(t, train.Data, train2.Data)
+	assert.Equal(t, test.Data, test2.Data)
+
+	_, _, err = m.Split(1, nil)
+	assert.Error(t, err)
+
+	_, _, err = m.Split(0.01, nil)
+	assert.Error(t, err)
+}
+
+func TestStratifiedSplit(t *testing.T) {
+	m := splitTestData()
+
+	train, test, err := m.StratifiedSplit(1, 0.2, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+	assert.Equal(t, 3, countClass(test, 0))
+	assert.Equal(t, 1, countClass(test, 1))
+	assert.Equal(t, 12, countClass(train, 0))
+	assert.Equal(t, 4, countClass(train, 1))
+	assert.Equal(t, splitIDs(m), splitIDs(train, test))
+}
+
+func TestKFold(t *testing.T) {
+	m := splitTestData()
+
+	folds, err := m.KFold(4, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+	assert.Len(t, folds, 4)
+
+	var tests []*Matrix
+	for _, fold := range folds {
+		assert.Equal(t, 15, fold.Train.Rows)
+		assert.Equal(t, 5, fold.Test.Rows)
+		assert.Equal(t, splitIDs(m), splitIDs(fold.Train, fold.Test))
+
+		tests = append(tests, fold.Test)
+	}
+
+	assert.Equal(t, splitIDs(m), splitIDs(tests...))
+
+	_, err = m.KFold(1, nil)
+	assert.Error(t, err)
+
+	_, err = m.KFold(21, nil)
+	assert.Error(t, err)
+}
+
+func TestStratifiedKFold(t *testing.T) {
+	m := splitTestData()
+
+	folds, err := m.StratifiedKFold(1, 5, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+
+	for _, fold := range folds {
+		assert.Equal(t, 3, countClass(fold.Test, 0))
+		assert.Equal(t, 1, countClass(fold.Test, 1))
+		assert.Equal(t, splitIDs(m), splitIDs(fold.Train, fold.Test))
+	}
+}
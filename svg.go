@@ -0,0 +1,163 @@
+package gosom
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+)
+
+// An SVG holds a vector image.
+type SVG struct {
+	Width  int
+	Height int
+
+	body      bytes.Buffer
+	gradients int
+}
+
+func newSVG(width, height int) canvas {
+	return &SVG{
+		Width:  width,
+		Height: height,
+	}
+}
+
+func (s *SVG) polygon(points []float64, c color.Color, title string) {
+	s.body.WriteString(`<polygon points="`)
+
+	for i := 0; i < len(points); i += 2 {
+		if i > 0 {
+			s.body.WriteByte(' ')
+		}
+
+		s.body.WriteString(formatCoordinate(points[i]))
+		s.body.WriteByte(',')
+		s.body.WriteString(formatCoordinate(points[i+1]))
+	}
+
+	s.body.WriteString(`" fill="`)
+	s.body.WriteString(hexColor(c))
+	s.body.WriteByte('"')
+
+	if title != "" {
+		fmt.Fprintf(&s.body, "><title>%s</title></polygon>\n", html.EscapeString(title))
+	} else {
+		s.body.WriteString("/>\n")
+	}
+}
+
+func (s *SVG) line(x1, y1, x2, y2 float64, c color.Color) {
+	fmt.Fprintf(&s.body, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s"/>`+"\n",
+		formatCoordinate(x1), formatCoordinate(y1), formatCoordinate(x2), formatCoordinate(y2), hexColor(c))
+}
+
+func (s *SVG) text(x, y float64, text string, c color.Color) {
+	fmt.Fprintf(&s.body, `<text x="%s" y="%s" fill="%s" font-family="monospace" font-size="12">%s</text>`+"\n",
+		formatCoordinate(x), formatCoordinate(y), hexColor(c), html.EscapeString(text))
+}
+
+func (s *SVG) gradient(x1, y1, x2, y2 float64, stops []color.Color) {
+	s.gradients++
+	id := fmt.Sprintf("gradient-%d", s.gradients)
+
+	fmt.Fprintf(&s.body, `<defs><linearGradient id="%s" x1="0" y1="0" x2="0" y2="1">`, id)
+
+	for i, c := range stops {
+		offset := float64(i) / float64(max(len(stops)-1, 1))
+		fmt.Fprintf(&s.body, `<stop offset="%s" stop-color="%s"/>`, formatCoordinate(offset), hexColor(c))
+	}
+
+	s.body.WriteString("</linearGradient></defs>\n")
+
+	fmt.Fprintf(&s.body, `<rect x="%s" y="%s" width="%s" height="%s" fill="url(#%s)"/>`+"\n",
+		formatCoordinate(x1), formatCoordinate(y1), formatCoordinate(x2-x1), formatCoordinate(y2-y1), id)
+}
+
+func (s *SVG) tooltips() bool {
+	return true
+}
+
+// WriteTo writes the SVG document to w.
+func (s *SVG) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		s.Width, s.Height, s.Width, s.Height)
+	buf.Write(s.body.Bytes())
+	buf.WriteString("</svg>\n")
+
+	return buf.WriteTo(w)
+}
+
+// svgImages returns the SVGs of vector canvases.
+func svgImages(canvases []canvas) []*SVG {
+	images := make([]*SVG, len(canvases))
+
+	for i, c := range canvases {
+		images[i] = c.(*SVG)
+	}
+
+	return images
+}
+
+// DrawDimensionsSVG draws the dimensions of the SOM as SVGs using the
+// specified options.
+func DrawDimensionsSVG(som *SOM, nodeWidth int, options DrawOptions) []*SVG {
+	return svgImages(drawDimensions(som, nodeWidth, options, newSVG))
+}
+
+// DrawUMatrixSVG draws the U-Matrix of the SOM as an SVG using the specified
+// options.
+func DrawUMatrixSVG(som *SOM, nodeWidth int, options DrawOptions) *SVG {
+	return drawValues(som, som.UMatrix(), nodeWidth, options, newSVG).(*SVG)
+}
+
+// DrawPMatrixSVG draws the P-Matrix of the SOM as an SVG using the Pareto
+// radius of data and the specified options.
+func DrawPMatrixSVG(som *SOM, data *Matrix, nodeWidth int, options DrawOptions) *SVG {
+	return drawValues(som, som.PMatrix(data, som.ParetoRadius(data)), nodeWidth, options, newSVG).(*SVG)
+}
+
+// DrawUStarMatrixSVG draws the U*-Matrix of the SOM as an SVG using the Pareto
+// radius of data and the specified options.
+func DrawUStarMatrixSVG(som *SOM, data *Matrix, nodeWidth int, options DrawOptions) *SVG {
+	return drawValues(som, som.UStarMatrix(data, som.ParetoRadius(data)), nodeWidth, options, newSVG).(*SVG)
+}
+
+// DrawHitMapSVG draws the number of rows in data that are mapped to each node
+// as an SVG. The size of a node is scaled by its hits.
+func DrawHitMapSVG(som *SOM, data *Matrix, nodeWidth int) *SVG {
+	return drawHits(som, som.Hits(data), nodeWidth, color.Black, newSVG).(*SVG)
+}
+
+// DrawClassHitMapsSVG draws a hit map for each distinct label in the specified
+// column of data and returns the labels and SVGs.
+func DrawClassHitMapsSVG(som *SOM, data *Matrix, column, nodeWidth int) ([]float64, []*SVG) {
+	labels, canvases := drawClassHits(som, data, column, nodeWidth, newSVG)
+	return labels, svgImages(canvases)
+}
+
+// DrawClustersSVG draws the clusters of the SOM as an SVG.
+func DrawClustersSVG(clustering *Clustering, nodeWidth int) *SVG {
+	return drawClusters(clustering, nodeWidth, newSVG).(*SVG)
+}
+
+// DrawProjectionSVG draws the projected nodes of the SOM connected to their
+// lattice neighbors as an SVG (see DrawProjection).
+func DrawProjectionSVG(som *SOM, projection *Matrix, size int) *SVG {
+	return drawProjection(som, projection, size, newSVG).(*SVG)
+}
+
+func formatCoordinate(f float64) string {
+	// adding zero turns negative zeros into positive zeros
+	return strconv.FormatFloat(math.Round(f*100)/100+0, 'f', -1, 64)
+}
+
+func hexColor(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
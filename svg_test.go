@@ -0,0 +1,124 @@
+package gosom
+
+import (
+	"bytes"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSVG(t *testing.T) {
+	svg := newSVG(10, 20)
+	svg.polygon([]float64{0, 0, 10, 0, 10, 10.005}, color.White, "a < b")
+	svg.text(1, 2.5, "foo", color.Black)
+
+	var buf bytes.Buffer
+	_, err := svg.(*SVG).WriteTo(&buf)
+	require.NoError(t, err)
+	require.Equal(t, `<svg xmlns="http://www.w3.org/2000/svg" width="10" height="20" viewBox="0 0 10 20">
+<polygon points="0,0 10,0 10,10.01" fill="#ffffff"><title>a &lt; b</title></polygon>
+<text x="1" y="2.5" fill="#000000" font-family="monospace" font-size="12">foo</text>
+</svg>
+`, buf.String())
+}
+
+func TestSVGGradient(t *testing.T) {
+	svg := newSVG(10, 20)
+	svg.gradient(0, 0, 10, 20, []color.Color{color.White, color.Black})
+
+	var buf bytes.Buffer
+	_, err := svg.(*SVG).WriteTo(&buf)
+	require.NoError(t, err)
+	require.Equal(t, `<svg xmlns="http://www.w3.org/2000/svg" width="10" height="20" viewBox="0 0 10 20">
+<defs><linearGradient id="gradient-1" x1="0" y1="0" x2="0" y2="1"><stop offset="0" stop-color="#ffffff"/><stop offset="1" stop-color="#000000"/></linearGradient></defs>
+<rect x="0" y="0" width="10" height="20" fill="url(#gradient-1)"/>
+</svg>
+`, buf.String())
+}
+
+func TestDrawUMatrixSVG(t *testing.T) {
+	som := NewSOM(2, 2)
+	som.InitializeWithZeroes(2)
+
+	svg := DrawUMatrixSVG(som, 10, DrawOptions{ColorMap: "gray"})
+	require.Equal(t, 20, svg.Width)
+	require.Equal(t, 20, svg.Height)
+
+	var buf bytes.Buffer
+	_, err := svg.WriteTo(&buf)
+	require.NoError(t, err)
+	require.Equal(t, 4, strings.Count(buf.String(), "<polygon"))
+	require.Contains(t, buf.String(), "<title>x: 1, y: 1, value: 0, weights: [0 0]</title>")
+}
+
+func TestDrawUMatrixSVGHexagonal(t *testing.T) {
+	som := NewSOM(2, 2)
+	som.Topology = "hexagonal"
+	som.InitializeWithZeroes(2)
+
+	svg := DrawUMatrixSVG(som, 10, DrawOptions{ColorMap: "gray"})
+	require.Equal(t, 25, svg.Width)
+	require.Equal(t, 21, svg.Height)
+
+	var buf bytes.Buffer
+	_, err := svg.WriteTo(&buf)
+	require.NoError(t, err)
+	require.Equal(t, 5, strings.Count(buf.String(), "<polygon"))
+	require.Equal(t, 4, strings.Count(buf.String(), "<title>"))
+}
+
+func TestDrawSVGs(t *testing.T) {
+	som := NewSOM(5, 5)
+	som.InitializeWithZeroes(3)
+	data := NewMatrix(slice)
+
+	dimensions := DrawDimensionsSVG(som, 5, DrawOptions{ColorMap: "viridis", Legend: true})
+	require.Len(t, dimensions, 3)
+
+	var buf bytes.Buffer
+	_, err := dimensions[0].WriteTo(&buf)
+	require.NoError(t, err)
+	require.Equal(t, 26, strings.Count(buf.String(), "<polygon"))
+	require.Equal(t, 9, strings.Count(buf.String(), "<stop "))
+	require.NotNil(t, DrawPMatrixSVG(som, data, 5, DrawOptions{}))
+	require.NotNil(t, DrawUStarMatrixSVG(som, data, 5, DrawOptions{}))
+	require.NotNil(t, DrawHitMapSVG(som, data, 5))
+	require.NotNil(t, DrawClustersSVG(WatershedClustering(som), 5))
+
+	labels, svgs := DrawClassHitMapsSVG(som, data, 2, 5)
+	require.Len(t, labels, 2)
+	require.Len(t, svgs, 2)
+}
+
+func TestDrawLabelsSVG(t *testing.T) {
+	som := NewSOM(2, 1)
+	som.InitializeWithZeroes(2)
+
+	svg := DrawUMatrixSVG(som, 20, DrawOptions{
+		ColorMap:  "gray",
+		Labels:    [][]string{{"a", "b"}, nil},
+		AllLabels: true,
+	})
+
+	var buf bytes.Buffer
+	_, err := svg.WriteTo(&buf)
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), `<text x="6.5" y="20" fill="#ffffff" font-family="monospace" font-size="12">b</text>`)
+	require.Equal(t, 2, strings.Count(buf.String(), "<text"))
+}
+
+func TestDrawProjectionSVG(t *testing.T) {
+	som := NewSOM(2, 2)
+	som.InitializeWithZeroes(2)
+
+	svg := DrawProjectionSVG(som, NewMatrix([][]float64{{0, 0}, {1, 0}, {0, 1}, {1, 1}}), 100)
+
+	var buf bytes.Buffer
+	_, err := svg.WriteTo(&buf)
+	require.NoError(t, err)
+	require.Equal(t, 4, strings.Count(buf.String(), "<line"))
+	require.Equal(t, 5, strings.Count(buf.String(), "<polygon"))
+	require.Contains(t, buf.String(), `<line x1="10" y1="10" x2="90" y2="10" stroke="#a0a0a0"/>`)
+}
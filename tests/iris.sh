@@ -2,10 +2,9 @@
 set -e
 
 # This test trains the SOM using the well known iris data set. The SOM is
-# expected to classify the flowers properly.
-#
-# Todo:
-# - split data set into a train and test only set.
+# expected to classify the flowers properly. The final cross-validation trains
+# and tests separate SOMs on stratified folds of the data set and the search
+# ranks combinations of parameters on a held-out split.
 
 echo "---> cleaning and creating './tmp'"
 rm -rf ./tmp
@@ -39,5 +38,11 @@ echo "---> plotting tuned SOM"
 echo "---> testing SOM"
 ../gosom test som.json data.csv -k 15
 
+echo "---> cross-validating SOM"
+../gosom crossval data.csv 20 20 --folds 5 --labels 4 -n gaussian -c soft -t 20000 -k 15
+
+echo "---> searching SOM parameters"
+../gosom tune best.json data.csv results.csv --sizes 10x10,20x20 -n cone,gaussian -c linear,soft -l 0.5,0.1 -t 20000 --labels 4 --metric interpolation -k 15
+
 echo "---> opening folder"
 open .
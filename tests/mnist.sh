@@ -14,26 +14,17 @@ wget http://yann.lecun.com/exdb/mnist/train-labels-idx1-ubyte.gz
 wget http://yann.lecun.com/exdb/mnist/t10k-images-idx3-ubyte.gz
 wget http://yann.lecun.com/exdb/mnist/t10k-labels-idx1-ubyte.gz
 
-echo "---> unzip data set"
-gunzip ./*
-
-echo "---> convert data set"
-python ../mnist.py
-
-echo "---> remove intermediary files"
-rm ./*-ubyte
-
 echo "---> preparing SOM"
-../gosom prepare som.json train.csv 28 28 -n gaussian -c soft
+../gosom prepare som.json train-images-idx3-ubyte.gz 28 28 -n gaussian -c soft --idx-labels train-labels-idx1-ubyte.gz
 
 echo "---> training SOM"
-../gosom train som.json train.csv -t 10000
+../gosom train som.json train-images-idx3-ubyte.gz -t 10000 --idx-labels train-labels-idx1-ubyte.gz
 
 echo "---> plotting trained SOM"
 ../gosom plot som.json .
 
 echo "---> testing SOM"
-../gosom test som.json test.csv -k 5 -q
+../gosom test som.json t10k-images-idx3-ubyte.gz -k 5 -q --idx-labels t10k-labels-idx1-ubyte.gz
 
 echo "---> opening folder"
 open .
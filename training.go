@@ -1,5 +1,7 @@
 package gosom
 
+import "math/rand"
+
 // A Training holds settings for a SOM training.
 type Training struct {
 	SOM                 *SOM
@@ -8,6 +10,40 @@ type Training struct {
 	FinalLearningRate   float64
 	InitialRadius       float64
 	FinalRadius         float64
+
+	// The algorithm that is recorded in the training state, e.g. "online" or
+	// "recurrent".
+	Algorithm string
+
+	// Source is used to select the training rows and break ties if set.
+	// Otherwise the global random number generator is used.
+	Source *Source
+
+	// Animation records snapshots of the SOM during the training if set.
+	Animation *Animation
+
+	random  *rand.Rand
+	weights []float32
+	nodes   *Node
+	scratch []float32
+}
+
+// A TrainingState is a serializable snapshot of a training that allows to
+// resume it exactly at the recorded step. The topology and precision of the
+// SOM are recorded to verify that it is resumed with the same lattice.
+type TrainingState struct {
+	Algorithm           string
+	Topology            string
+	Precision           int `json:",omitempty"`
+	Step                int
+	Steps               int
+	InitialLearningRate float64
+	FinalLearningRate   float64
+	InitialRadius       float64
+	FinalRadius         float64
+	Seed                int64
+	Source              *Source `json:",omitempty"`
+	Fingerprint         string  `json:",omitempty"`
 }
 
 // NewTraining returns a new Training.
@@ -19,7 +55,44 @@ func NewTraining(som *SOM, steps int, ilr, flr, ir, fr float64) *Training {
 		FinalLearningRate:   flr,
 		InitialRadius:       ir,
 		FinalRadius:         fr,
+		Algorithm:           "online",
+	}
+}
+
+// State returns the state of the training before the specified step. The seed
+// and the fingerprint of the data are recorded to reproduce the training.
+func (t *Training) State(step int, seed int64, data *Matrix) *TrainingState {
+	state := &TrainingState{
+		Algorithm:           t.Algorithm,
+		Topology:            t.SOM.Topology,
+		Precision:           t.SOM.Precision,
+		Step:                step,
+		Steps:               t.Steps,
+		InitialLearningRate: t.InitialLearningRate,
+		FinalLearningRate:   t.FinalLearningRate,
+		InitialRadius:       t.InitialRadius,
+		FinalRadius:         t.FinalRadius,
+		Seed:                seed,
+		Fingerprint:         Fingerprint(data),
+	}
+
+	if t.Source != nil {
+		state.Source = &Source{State: t.Source.State}
 	}
+
+	return state
+}
+
+// Training returns a training for the SOM that continues from the state.
+func (s *TrainingState) Training(som *SOM) *Training {
+	training := NewTraining(som, s.Steps, s.InitialLearningRate, s.FinalLearningRate, s.InitialRadius, s.FinalRadius)
+	training.Algorithm = s.Algorithm
+
+	if s.Source != nil {
+		training.Source = &Source{State: s.Source.State}
+	}
+
+	return training
 }
 
 // Progress returns the current progress based on step and steps.
@@ -38,3 +111,28 @@ func (t *Training) Radius(step int) float64 {
 	r := t.InitialRadius - t.FinalRadius
 	return r*t.SOM.CF(t.Progress(step)) + t.FinalRadius
 }
+
+// intn returns a random number in [0,n) using the source if set.
+func (t *Training) intn(n int) int {
+	if t.Source == nil {
+		return rand.Intn(n)
+	}
+
+	if t.random == nil {
+		t.random = rand.New(t.Source)
+	}
+
+	return t.random.Intn(n)
+}
+
+// observe snapshots the SOM if an animation is set and the step is a multiple
+// of the animation interval or the end of the training.
+func (t *Training) observe(step int) {
+	if t.Animation == nil {
+		return
+	}
+
+	if step == t.Steps || (t.Animation.Interval > 0 && step%t.Animation.Interval == 0) {
+		t.Animation.Snapshot(step)
+	}
+}
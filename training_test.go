@@ -1,6 +1,7 @@
 package gosom
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -20,3 +21,49 @@ func TestTraining(t *testing.T) {
 	require.Equal(t, 0.25, tr.LearningRate(5))
 	require.Equal(t, 5.0, tr.Radius(5))
 }
+
+func TestTrainingResume(t *testing.T) {
+	data := NewMatrix([][]float64{{0, 0}, {1, 1}, {0, 1}, {1, 0}, {0.5, 0.5}})
+
+	prepare := func() *SOM {
+		som := NewSOM(3, 3)
+		som.InitializeWithZeroes(2)
+		return som
+	}
+
+	// train without interruption
+	som1 := prepare()
+	tr1 := NewTraining(som1, 20, 0.5, 0.1, 2, 0.5)
+	tr1.Source = NewSource(42)
+	som1.Train(data, tr1)
+
+	// train with interruption
+	som2 := prepare()
+	tr2 := NewTraining(som2, 20, 0.5, 0.1, 2, 0.5)
+	tr2.Source = NewSource(42)
+
+	for step := 0; step < 10; step++ {
+		som2.Step(data, step, tr2)
+	}
+
+	model := NewModel(som2)
+	model.Checkpoint = tr2.State(10, 42, data)
+
+	var buf bytes.Buffer
+	require.NoError(t, model.Save(&buf))
+
+	model, err := LoadModel(&buf)
+	require.NoError(t, err)
+	require.Equal(t, 10, model.Checkpoint.Step)
+	require.Equal(t, "online", model.Checkpoint.Algorithm)
+	require.Equal(t, Fingerprint(data), model.Checkpoint.Fingerprint)
+	require.Equal(t, "rectangular", model.Checkpoint.Topology)
+	require.Equal(t, 0, model.Checkpoint.Precision)
+
+	tr3 := model.Checkpoint.Training(model.SOM)
+	for step := model.Checkpoint.Step; step < tr3.Steps; step++ {
+		model.SOM.Step(data, step, tr3)
+	}
+
+	require.Equal(t, som1.Nodes, model.SOM.Nodes)
+}
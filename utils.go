@@ -2,6 +2,7 @@ package gosom
 
 import (
 	"math"
+	"sort"
 
 	"github.com/gonum/floats"
 )
@@ -24,6 +25,37 @@ func avg(v []float64) float64 {
 	return floats.Sum(v) / float64(len(v))
 }
 
+func stdDev(v []float64) float64 {
+	m := avg(v)
+	s := 0.0
+
+	for _, f := range v {
+		s += (f - m) * (f - m)
+	}
+
+	return math.Sqrt(s / float64(len(v)))
+}
+
+// percentile returns the linearly interpolated percentile p [0..100] of v.
+func percentile(v []float64, p float64) float64 {
+	if len(v) == 0 {
+		return math.NaN()
+	}
+
+	sorted := make([]float64, len(v))
+	copy(sorted, v)
+	sort.Float64s(sorted)
+
+	r := math.Max(0, math.Min(1, p/100.0)) * float64(len(sorted)-1)
+	i := int(r)
+
+	if i+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+
+	return sorted[i] + (sorted[i+1]-sorted[i])*(r-float64(i))
+}
+
 func clearNANs(in []float64) []float64 {
 	var out []float64
 
@@ -21,6 +21,19 @@ func TestAvg(t *testing.T) {
 	assert.Equal(t, 1.0, avg([]float64{0, 1, 2}))
 }
 
+func TestStdDev(t *testing.T) {
+	assert.Equal(t, 0.0, stdDev([]float64{1, 1, 1}))
+	assert.Equal(t, 1.0, stdDev([]float64{0, 2}))
+}
+
+func TestPercentile(t *testing.T) {
+	assert.Equal(t, 0.0, percentile([]float64{2, 0, 1}, 0))
+	assert.Equal(t, 1.0, percentile([]float64{2, 0, 1}, 50))
+	assert.Equal(t, 1.5, percentile([]float64{2, 0, 1}, 75))
+	assert.Equal(t, 2.0, percentile([]float64{2, 0, 1}, 100))
+	assert.True(t, math.IsNaN(percentile(nil, 50)))
+}
+
 func TestClearNANs(t *testing.T) {
 	out := clearNANs([]float64{math.NaN(), 1.0, math.NaN(), 0.5, math.NaN()})
 	assert.Equal(t, []float64{1.0, 0.5}, out)
@@ -1,78 +1,468 @@
 package gosom
 
 import (
+	"fmt"
 	"image"
 	"image/color"
+	"math"
+	"strconv"
 
 	"github.com/gonum/floats"
-	"github.com/llgcode/draw2d/draw2dimg"
-	"github.com/llgcode/draw2d/draw2dkit"
 )
 
+// DrawOptions configures the rendering of images.
+type DrawOptions struct {
+	// The color map used to render values (see Color).
+	ColorMap string
+
+	// Whether a color bar with the minimum and maximum value is drawn.
+	Legend bool
+
+	// Whether the color map is reversed.
+	Invert bool
+
+	// The titles drawn above the images.
+	Titles []string
+
+	// The labels of every node that are drawn on top of the map (see
+	// SOM.NodeLabels).
+	Labels [][]string
+
+	// Whether all labels or only the most frequent label of a node are drawn.
+	AllLabels bool
+}
+
+// color returns the color of the value [0..1].
+func (o DrawOptions) color(value float64) color.Color {
+	if o.Invert {
+		value = 1 - value
+	}
+
+	return Color(o.ColorMap, value)
+}
+
+// title returns the title for the image at index i.
+func (o DrawOptions) title(i int) string {
+	if i < len(o.Titles) {
+		return o.Titles[i]
+	}
+
+	return ""
+}
+
 // DrawDimensions draws the dimensions of the SOM as images.
 func DrawDimensions(som *SOM, nodeWidth int) []image.Image {
+	return DrawDimensionsWithOptions(som, nodeWidth, DrawOptions{
+		ColorMap: "gray",
+	})
+}
+
+// DrawDimensionsWithOptions draws the dimensions of the SOM as images using
+// the specified options.
+func DrawDimensionsWithOptions(som *SOM, nodeWidth int, options DrawOptions) []image.Image {
+	return rasterImages(drawDimensions(som, nodeWidth, options, newRaster))
+}
+
+func drawDimensions(som *SOM, nodeWidth int, options DrawOptions, newCanvas newCanvasFunc) []canvas {
 	matrix := som.WeightMatrix()
-	images := make([]image.Image, som.Dimensions())
+	canvases := make([]canvas, som.Dimensions())
 
-	for i := 0; i < som.Dimensions(); i++ {
-		img := image.NewRGBA(image.Rect(0, 0, som.Width*nodeWidth, som.Height*nodeWidth))
-		gc := draw2dimg.NewGraphicContext(img)
+	for i := range canvases {
+		canvases[i] = drawDimension(som, matrix, i, nodeWidth, options, newCanvas)
+	}
 
-		for _, node := range som.Nodes {
-			r := matrix.Maximums[i] - matrix.Minimums[i]
-			g := uint8(((node.Weights[i] - matrix.Minimums[i]) / r) * 255)
-			gc.SetFillColor(&color.Gray{Y: g})
+	return canvases
+}
 
-			x := node.X() * nodeWidth
-			y := node.Y() * nodeWidth
-			draw2dkit.Rectangle(gc, float64(x), float64(y), float64(x+nodeWidth), float64(y+nodeWidth))
-			gc.Fill()
-		}
+// drawDimension draws the dimension i of the SOM using the bounds of the
+// weight matrix.
+func drawDimension(som *SOM, matrix *Matrix, i, nodeWidth int, options DrawOptions, newCanvas newCanvasFunc) canvas {
+	f := newFrame(som, nodeWidth, options.title(i), options.Legend, newCanvas)
 
-		images[i] = img
+	for _, node := range som.Nodes {
+		value := normalize(node.Weights[i], matrix.Minimums[i], matrix.Maximums[i])
+		f.cell(node, 1, options.color(value), "value", node.Weights[i])
 	}
 
-	return images
+	if options.Legend {
+		f.legend(options, matrix.Minimums[i], matrix.Maximums[i])
+	}
+
+	f.labels(options.Labels, options.AllLabels)
+
+	return f.canvas
 }
 
 // DrawUMatrix draws the U-Matrix of the SOM as an image.
 func DrawUMatrix(som *SOM, nodeWidth int) image.Image {
-	img := image.NewRGBA(image.Rect(0, 0, som.Width*nodeWidth, som.Height*nodeWidth))
-	gc := draw2dimg.NewGraphicContext(img)
+	return DrawUMatrixWithOptions(som, nodeWidth, DrawOptions{
+		ColorMap: "gray",
+		Invert:   true,
+	})
+}
+
+// DrawUMatrixWithOptions draws the U-Matrix of the SOM as an image using the
+// specified options.
+func DrawUMatrixWithOptions(som *SOM, nodeWidth int, options DrawOptions) image.Image {
+	return drawValues(som, som.UMatrix(), nodeWidth, options, newRaster).(*raster).img
+}
+
+// DrawPMatrix draws the P-Matrix of the SOM as an image using the Pareto
+// radius of data.
+func DrawPMatrix(som *SOM, data *Matrix, nodeWidth int) image.Image {
+	return DrawPMatrixWithOptions(som, data, nodeWidth, DrawOptions{
+		ColorMap: "gray",
+	})
+}
+
+// DrawPMatrixWithOptions draws the P-Matrix of the SOM as an image using the
+// Pareto radius of data and the specified options.
+func DrawPMatrixWithOptions(som *SOM, data *Matrix, nodeWidth int, options DrawOptions) image.Image {
+	return drawValues(som, som.PMatrix(data, som.ParetoRadius(data)), nodeWidth, options, newRaster).(*raster).img
+}
+
+// DrawUStarMatrix draws the U*-Matrix of the SOM as an image using the Pareto
+// radius of data.
+func DrawUStarMatrix(som *SOM, data *Matrix, nodeWidth int) image.Image {
+	return DrawUStarMatrixWithOptions(som, data, nodeWidth, DrawOptions{
+		ColorMap: "gray",
+		Invert:   true,
+	})
+}
+
+// DrawUStarMatrixWithOptions draws the U*-Matrix of the SOM as an image using
+// the Pareto radius of data and the specified options.
+func DrawUStarMatrixWithOptions(som *SOM, data *Matrix, nodeWidth int, options DrawOptions) image.Image {
+	return drawValues(som, som.UStarMatrix(data, som.ParetoRadius(data)), nodeWidth, options, newRaster).(*raster).img
+}
+
+// drawValues draws one value per node using the specified options.
+func drawValues(som *SOM, values []float64, nodeWidth int, options DrawOptions, newCanvas newCanvasFunc) canvas {
+	f := newFrame(som, nodeWidth, options.title(0), options.Legend, newCanvas)
+
+	min := floats.Min(values)
+	max := floats.Max(values)
+
+	for i, node := range som.Nodes {
+		f.cell(node, 1, options.color(normalize(values[i], min, max)), "value", values[i])
+	}
+
+	if options.Legend {
+		f.legend(options, min, max)
+	}
+
+	f.labels(options.Labels, options.AllLabels)
+
+	return f.canvas
+}
+
+// DrawHitMap draws the number of rows in data that are mapped to each node as
+// an image. The size of a node is scaled by its hits.
+func DrawHitMap(som *SOM, data *Matrix, nodeWidth int) image.Image {
+	return drawHits(som, som.Hits(data), nodeWidth, color.Black, newRaster).(*raster).img
+}
+
+// DrawClassHitMaps draws a hit map for each distinct label in the specified
+// column of data and returns the labels and images.
+func DrawClassHitMaps(som *SOM, data *Matrix, column, nodeWidth int) ([]float64, []image.Image) {
+	labels, canvases := drawClassHits(som, data, column, nodeWidth, newRaster)
+	return labels, rasterImages(canvases)
+}
+
+func drawClassHits(som *SOM, data *Matrix, column, nodeWidth int, newCanvas newCanvasFunc) ([]float64, []canvas) {
+	labels, hits := som.ClassHits(data, column)
+	canvases := make([]canvas, len(labels))
+
+	for i := range labels {
+		canvases[i] = drawHits(som, hits[i], nodeWidth, clusterColor(i), newCanvas)
+	}
+
+	return labels, canvases
+}
 
-	values := make([]float64, len(som.Nodes))
+func drawHits(som *SOM, hits []int, nodeWidth int, c color.Color, newCanvas newCanvasFunc) canvas {
+	f := newFrame(som, nodeWidth, "", false, newCanvas)
+	f.canvas.polygon(rectangle(0, 0, f.width, f.height), color.White, "")
+
+	max := 0
+	for _, h := range hits {
+		if h > max {
+			max = h
+		}
+	}
 
 	for i, node := range som.Nodes {
-		var distances []float64
+		if hits[i] == 0 {
+			continue
+		}
+
+		// scale the area of the node by its hits
+		f.cell(node, math.Sqrt(float64(hits[i])/float64(max)), c, "hits", float64(hits[i]))
+	}
+
+	return f.canvas
+}
+
+// DrawClusters draws the clusters of the SOM as an image.
+func DrawClusters(clustering *Clustering, nodeWidth int) image.Image {
+	return drawClusters(clustering, nodeWidth, newRaster).(*raster).img
+}
+
+func drawClusters(clustering *Clustering, nodeWidth int, newCanvas newCanvasFunc) canvas {
+	som := clustering.SOM
+	f := newFrame(som, nodeWidth, "", false, newCanvas)
+
+	for i, node := range som.Nodes {
+		cluster := clustering.Clusters[i]
+		f.cell(node, 1, clusterColor(cluster), "cluster", float64(cluster))
+	}
+
+	return f.canvas
+}
+
+// DrawProjection draws the projected nodes of the SOM (see SOM.MDS and
+// SOM.Sammon) connected to their lattice neighbors as an image of the
+// specified size. Folded or twisted maps show up as crossing edges.
+func DrawProjection(som *SOM, projection *Matrix, size int) image.Image {
+	return drawProjection(som, projection, size, newRaster).(*raster).img
+}
+
+func drawProjection(som *SOM, projection *Matrix, size int, newCanvas newCanvasFunc) canvas {
+	const margin = 10
+	const marker = 2
+
+	c := newCanvas(size, size)
+	c.polygon(rectangle(0, 0, float64(size), float64(size)), color.White, "")
+
+	// scale the projection uniformly to fit the canvas
+	minimums, maximums := projection.Minimums, projection.Maximums
+	extent := math.Max(maximums[0]-minimums[0], maximums[1]-minimums[1])
+	scale := 0.0
+	if extent > 0 {
+		scale = (float64(size) - 2*margin) / extent
+	}
+
+	point := func(i int) (float64, float64) {
+		x := margin + (projection.Data[i][0]-minimums[0])*scale
+		y := margin + (projection.Data[i][1]-minimums[1])*scale
+		return x, y
+	}
+
+	for i := range som.Nodes {
+		for _, j := range som.adjacent(i) {
+			if j > i {
+				x1, y1 := point(i)
+				x2, y2 := point(j)
+				c.line(x1, y1, x2, y2, color.Gray{Y: 160})
+			}
+		}
+	}
 
-		if node.X() > 1 {
-			distances = append(distances, som.D(node.Weights, som.N(node.X()-1, node.Y()).Weights))
+	for i, node := range som.Nodes {
+		var title string
+		if c.tooltips() {
+			title = fmt.Sprintf("x: %d, y: %d, weights: %g", node.X(), node.Y(), node.Weights)
 		}
-		if node.X()+1 < som.Width {
-			distances = append(distances, som.D(node.Weights, som.N(node.X()+1, node.Y()).Weights))
+
+		x, y := point(i)
+		c.polygon(rectangle(x-marker, y-marker, x+marker, y+marker), color.Black, title)
+	}
+
+	return c
+}
+
+const (
+	titleHeight  = 18
+	legendWidth  = 80
+	legendMargin = 8
+	legendBar    = 12
+
+	// legendStops matches the number of stops of the color maps
+	legendStops = 9
+)
+
+// A frame lays out a map with an optional title and legend on a canvas.
+type frame struct {
+	som       *SOM
+	nodeWidth float64
+	width     float64
+	height    float64
+	top       float64
+	canvas    canvas
+	fills     []color.Color
+}
+
+// newFrame returns a frame with a canvas that fits the map and the optional
+// title and legend.
+func newFrame(som *SOM, nodeWidth int, title string, legend bool, newCanvas newCanvasFunc) *frame {
+	f := &frame{
+		som:       som,
+		nodeWidth: float64(nodeWidth),
+		fills:     make([]color.Color, len(som.Nodes)),
+	}
+
+	f.width, f.height = mapSize(som, nodeWidth)
+
+	width := int(math.Ceil(f.width))
+	height := int(math.Ceil(f.height))
+
+	if legend {
+		width += legendWidth
+	}
+
+	if title != "" {
+		f.top = titleHeight
+		height += titleHeight
+		width = max(width, len(title)*7+8)
+	}
+
+	f.canvas = newCanvas(width, height)
+
+	// hexagonal cells do not cover the whole map
+	if legend || title != "" || som.Topology == "hexagonal" {
+		f.canvas.polygon(rectangle(0, 0, float64(width), float64(height)), color.White, "")
+	}
+
+	if title != "" {
+		f.canvas.text(4, 13, title, color.Black)
+	}
+
+	return f
+}
+
+// mapSize returns the size of the map. Hexagonal cells are sized so that the
+// distance between opposite sides equals the node width.
+func mapSize(som *SOM, nodeWidth int) (float64, float64) {
+	w := float64(nodeWidth)
+
+	if som.Topology != "hexagonal" {
+		return float64(som.Width) * w, float64(som.Height) * w
+	}
+
+	width := float64(som.Width) * w
+	if som.Height > 1 {
+		width += w / 2
+	}
+
+	r := w / math.Sqrt(3)
+	height := float64(som.Height-1)*1.5*r + 2*r
+
+	return width, height
+}
+
+// center returns the center of the node on the canvas.
+func (f *frame) center(node *Node) (float64, float64) {
+	c := f.som.Coordinates(node)
+
+	if f.som.Topology != "hexagonal" {
+		return (c[0] + 0.5) * f.nodeWidth, (c[1]+0.5)*f.nodeWidth + f.top
+	}
+
+	return (c[0] + 0.5) * f.nodeWidth, c[1]*f.nodeWidth + f.nodeWidth/math.Sqrt(3) + f.top
+}
+
+// cell fills the cell of the node scaled by the specified factor. The name
+// and value are included in the tooltip if supported by the canvas.
+func (f *frame) cell(node *Node, scale float64, c color.Color, name string, value float64) {
+	x, y := f.center(node)
+
+	var title string
+	if f.canvas.tooltips() {
+		title = nodeTitle(node, name, value)
+	}
+
+	if scale == 1 {
+		f.fills[node.Y()*f.som.Width+node.X()] = c
+	}
+
+	if f.som.Topology != "hexagonal" {
+		h := f.nodeWidth * scale / 2
+		f.canvas.polygon(rectangle(x-h, y-h, x+h, y+h), c, title)
+		return
+	}
+
+	r := f.nodeWidth / math.Sqrt(3) * scale
+	points := make([]float64, 0, 12)
+
+	for i := 0; i < 6; i++ {
+		a := math.Pi / 180 * float64(60*i-90)
+		points = append(points, x+r*math.Cos(a), y+r*math.Sin(a))
+	}
+
+	f.canvas.polygon(points, c, title)
+}
+
+// legend draws a vertical color bar right of the map that is labeled with the
+// minimum and maximum value.
+func (f *frame) legend(options DrawOptions, minimum, maximum float64) {
+	x := math.Ceil(f.width) + legendMargin
+	height := int(math.Ceil(f.height))
+
+	stops := make([]color.Color, legendStops)
+	for i := range stops {
+		stops[i] = options.color(1 - float64(i)/float64(legendStops-1))
+	}
+
+	f.canvas.gradient(x, f.top, x+legendBar, f.top+float64(height), stops)
+
+	f.canvas.text(x+legendBar+4, f.top+10, strconv.FormatFloat(maximum, 'g', 3, 64), color.Black)
+	f.canvas.text(x+legendBar+4, f.top+float64(height)-2, strconv.FormatFloat(minimum, 'g', 3, 64), color.Black)
+}
+
+// labels draws the first or all labels of every node centered on its cell.
+func (f *frame) labels(labels [][]string, all bool) {
+	const lineHeight = 12
+
+	for i, node := range f.som.Nodes {
+		if i >= len(labels) || len(labels[i]) == 0 {
+			continue
 		}
-		if node.Y() > 1 {
-			distances = append(distances, som.D(node.Weights, som.N(node.X(), node.Y()-1).Weights))
+
+		lines := labels[i][:1]
+		if all {
+			lines = labels[i]
 		}
-		if node.Y()+1 < som.Height {
-			distances = append(distances, som.D(node.Weights, som.N(node.X(), node.Y()+1).Weights))
+
+		x, y := f.center(node)
+		c := textColor(f.fills[i])
+		top := y - float64(len(lines)*lineHeight)/2
+
+		for j, line := range lines {
+			f.canvas.text(x-float64(len(line))*3.5, top+float64((j+1)*lineHeight)-2, line, c)
 		}
+	}
+}
 
-		values[i] = avg(distances)
+// textColor returns black or white depending on the brightness of the
+// background.
+func textColor(background color.Color) color.Color {
+	if background == nil {
+		return color.Black
 	}
 
-	min := floats.Min(values)
-	max := floats.Max(values)
+	r, g, b, _ := background.RGBA()
+	if 0.299*float64(r)+0.587*float64(g)+0.114*float64(b) < 0x8000 {
+		return color.White
+	}
 
-	for i, node := range som.Nodes {
-		g := 255 - uint8(((values[i]-min)/max-min)*255)
-		gc.SetFillColor(&color.Gray{Y: g})
+	return color.Black
+}
+
+// rectangle returns the polygon points of a rectangle.
+func rectangle(x1, y1, x2, y2 float64) []float64 {
+	return []float64{x1, y1, x2, y1, x2, y2, x1, y2}
+}
+
+// nodeTitle returns a description of the node and its value.
+func nodeTitle(node *Node, name string, value float64) string {
+	return fmt.Sprintf("x: %d, y: %d, %s: %g, weights: %g", node.X(), node.Y(), name, value, node.Weights)
+}
 
-		x := node.X() * nodeWidth
-		y := node.Y() * nodeWidth
-		draw2dkit.Rectangle(gc, float64(x), float64(y), float64(x+nodeWidth), float64(y+nodeWidth))
-		gc.Fill()
+// normalize returns the value scaled from [min..max] to [0..1].
+func normalize(value, min, max float64) float64 {
+	if max == min {
+		return 0
 	}
 
-	return img
+	return (value - min) / (max - min)
 }
@@ -1,6 +1,8 @@
 package gosom
 
 import (
+	"image"
+	"image/color"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -13,9 +15,81 @@ func TestDrawDimensions(t *testing.T) {
 	require.NotNil(t, DrawDimensions(som, 5))
 }
 
+func TestDrawDimensionsWithOptions(t *testing.T) {
+	som := NewSOM(5, 5)
+	som.InitializeWithZeroes(2)
+
+	images := DrawDimensionsWithOptions(som, 5, DrawOptions{
+		ColorMap: "viridis",
+		Legend:   true,
+		Titles:   []string{"foo"},
+	})
+	require.Len(t, images, 2)
+	require.Equal(t, image.Rect(0, 0, 25+legendWidth, 25+titleHeight), images[0].Bounds())
+	require.Equal(t, image.Rect(0, 0, 25+legendWidth, 25), images[1].Bounds())
+}
+
 func TestDrawUMatrix(t *testing.T) {
 	som := NewSOM(5, 5)
 	som.InitializeWithZeroes(2)
 
 	require.NotNil(t, DrawUMatrix(som, 5))
 }
+
+func TestDrawLabels(t *testing.T) {
+	som := NewSOM(2, 1)
+	som.InitializeWithZeroes(1)
+
+	img := DrawUMatrixWithOptions(som, 20, DrawOptions{
+		ColorMap: "gray",
+		Labels:   [][]string{{"a"}, nil},
+	})
+	require.NotNil(t, img)
+	require.Equal(t, textColor(color.White), color.Black)
+	require.Equal(t, textColor(color.Black), color.White)
+}
+
+func TestDrawProjection(t *testing.T) {
+	som := NewSOM(5, 5)
+	som.InitializeWithRandomValues(NewMatrix([][]float64{{0, 0}, {1, 1}}))
+
+	img := DrawProjection(som, som.Sammon(10), 100)
+	require.Equal(t, image.Rect(0, 0, 100, 100), img.Bounds())
+}
+
+func TestDrawClusters(t *testing.T) {
+	som := NewSOM(5, 5)
+	som.InitializeWithZeroes(2)
+
+	require.NotNil(t, DrawClusters(WatershedClustering(som), 5))
+}
+
+func TestDrawPMatrix(t *testing.T) {
+	som := NewSOM(5, 5)
+	som.InitializeWithZeroes(3)
+
+	require.NotNil(t, DrawPMatrix(som, NewMatrix(slice), 5))
+}
+
+func TestDrawUStarMatrix(t *testing.T) {
+	som := NewSOM(5, 5)
+	som.InitializeWithZeroes(3)
+
+	require.NotNil(t, DrawUStarMatrix(som, NewMatrix(slice), 5))
+}
+
+func TestDrawHitMap(t *testing.T) {
+	som := NewSOM(5, 5)
+	som.InitializeWithZeroes(2)
+
+	require.NotNil(t, DrawHitMap(som, NewMatrix(slice), 5))
+}
+
+func TestDrawClassHitMaps(t *testing.T) {
+	som := NewSOM(5, 5)
+	som.InitializeWithZeroes(3)
+
+	labels, images := DrawClassHitMaps(som, NewMatrix(slice), 2, 5)
+	require.Equal(t, []float64{0.0, 1.0}, labels)
+	require.Len(t, images, 2)
+}